@@ -0,0 +1,34 @@
+package demo
+
+// SetBannerBottom moves the title banner to the bottom of the screen, the
+// scroller and the other effects filling the space above it. Off, the
+// default, keeps the banner at the top.
+func (g *Game) SetBannerBottom(on bool) {
+	g.bannerBottom = on
+}
+
+// bannerTop returns the first row of the title banner
+func (g *Game) bannerTop() int {
+	if g.bannerBottom {
+		return g.height - g.bannerHeight
+	}
+	return 0
+}
+
+// playfieldTop returns the first row of the space the banner leaves for the
+// scroller, logos and cubes; it is g.height-g.bannerHeight rows tall
+func (g *Game) playfieldTop() int {
+	if g.bannerBottom {
+		return 0
+	}
+	return g.bannerHeight
+}
+
+// bannerEdge returns the row where the banner meets the playfield, for the
+// indicators drawn along it
+func (g *Game) bannerEdge() int {
+	if g.bannerBottom {
+		return g.bannerTop()
+	}
+	return g.bannerHeight
+}
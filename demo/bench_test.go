@@ -0,0 +1,112 @@
+package demo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The benchmarks time the Go side of each effect, one Update and Draw per
+// op. Ebiten batches the GPU work, which flushGPU waits for once at the end.
+
+func BenchmarkCopperBars(b *testing.B) {
+	needGPU(b)
+	c := NewCopperBars(loadAssetImage(b, "bars.png"))
+	dst := ebiten.NewImage(screenWidth, defaultBannerHeight)
+	b.ReportAllocs()
+	for b.Loop() {
+		c.Update(1)
+		c.Draw(dst)
+	}
+	flushGPU(dst)
+}
+
+func BenchmarkScroller(b *testing.B) {
+	needGPU(b)
+	s := NewScroller(NewFont(loadAssetImage(b, "font.png")), "THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG 0123456789 ")
+	dst := ebiten.NewImage(screenWidth, screenHeight)
+	b.ReportAllocs()
+	for b.Loop() {
+		s.Update(1)
+		s.Draw(dst, defaultBannerHeight, screenHeight-defaultBannerHeight)
+	}
+	flushGPU(dst)
+}
+
+// BenchmarkDrawScrollText draws the demo's scroller as the game does, over
+// the playfield below the banner
+func BenchmarkDrawScrollText(b *testing.B) {
+	g := testGame(b)
+	dst := ebiten.NewImage(g.width, g.height)
+	b.ReportAllocs()
+	for b.Loop() {
+		g.scroller.Update(g.step)
+		g.drawScrollText(dst)
+	}
+	flushGPU(dst)
+}
+
+// TestScrollerDrawAllocs caps the allocations of a scroller frame. Ebiten's
+// SubImage allocates the *Image each scanline blits from; anything more, such
+// as options made per line, is a regression. Just after the start the text
+// doesn't wrap, so every scanline is a single blit.
+func TestScrollerDrawAllocs(t *testing.T) {
+	needGPU(t)
+	s := NewScroller(NewFont(loadAssetImage(t, "font.png")), "THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG ")
+	s.Update(1)
+	lines := screenHeight - defaultBannerHeight
+	dst := ebiten.NewImage(screenWidth, screenHeight)
+	allocs := testing.AllocsPerRun(20, func() {
+		s.Draw(dst, defaultBannerHeight, lines)
+	})
+	if allocs > float64(lines+2) {
+		t.Errorf("%v allocations per frame, want at most one per scanline (%d)", allocs, lines)
+	}
+}
+
+func BenchmarkCube(b *testing.B) {
+	needGPU(b)
+	c := NewCube3D(cubeSize)
+	c.SetSpin(defaultCubeSpin(0))
+	dst := ebiten.NewImage(screenWidth, screenHeight)
+	b.ReportAllocs()
+	for b.Loop() {
+		c.Spin(1)
+		c.Draw(dst, screenWidth/2, screenHeight/2)
+	}
+	flushGPU(dst)
+}
+
+// BenchmarkCubeSoftware fills the faces of a turning cube into an
+// *image.RGBA, timing the span filling alone; it needs no GPU
+func BenchmarkCubeSoftware(b *testing.B) {
+	c := NewCube3D(cubeSize * 4)
+	c.SetSpin(defaultCubeSpin(0))
+	dst := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	points := make([]float64, 0, 8)
+	b.ReportAllocs()
+	for b.Loop() {
+		c.Spin(1)
+		projected := c.ProjectedVertices(160, 120)
+		for i, face := range cubeFaces {
+			points = points[:0]
+			for _, vi := range face {
+				points = append(points, projected[vi][0], projected[vi][1])
+			}
+			drawPolygon(dst, points, defaultCubePalette[i])
+		}
+	}
+}
+
+func BenchmarkRotozoom(b *testing.B) {
+	needGPU(b)
+	r := NewRotozoom(loadAssetImage(b, "coco.png"))
+	dst := ebiten.NewImage(screenWidth, screenHeight)
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Update()
+		r.Draw(dst)
+	}
+	flushGPU(dst)
+}
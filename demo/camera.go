@@ -0,0 +1,72 @@
+package demo
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Free camera: zoom from 1 (the whole picture, no zooming out past it) to
+// cameraMaxZoom, doubling every cameraWheelSteps notches of the wheel
+const (
+	cameraMaxZoom    = 8.0
+	cameraWheelSteps = 4.0
+)
+
+// updateCamera zooms the demo picture with the mouse wheel around the
+// cursor and pans it while the right button is held. Only the final blit of
+// mainCanvas moves; the effects keep drawing at their usual coordinates.
+func (g *Game) updateCamera() {
+	if inpututil.IsKeyJustPressed(g.keys.CameraReset) {
+		g.resetCamera()
+	}
+	if g.state != "demo" {
+		return
+	}
+
+	x, y := g.cursorPosition()
+	if _, wheel := ebiten.Wheel(); wheel != 0 {
+		// Keep the canvas point under the cursor where it is
+		zoom := math.Max(1, math.Min(g.camZoom*math.Pow(2, wheel/cameraWheelSteps), cameraMaxZoom))
+		px, py := (x-g.camX)/g.camZoom, (y-g.camY)/g.camZoom
+		g.camZoom = zoom
+		g.camX, g.camY = x-px*zoom, y-py*zoom
+		g.idleDrawn = false
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+		if g.camDragging {
+			g.camX += x - g.camDragX
+			g.camY += y - g.camDragY
+			g.idleDrawn = false
+		}
+		g.camDragX, g.camDragY = x, y
+	}
+	g.camDragging = ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+
+	// The zoomed canvas always covers the screen
+	g.camX = math.Max(float64(g.width)*(1-g.camZoom), math.Min(g.camX, 0))
+	g.camY = math.Max(float64(g.height)*(1-g.camZoom), math.Min(g.camY, 0))
+}
+
+// resetCamera shows the whole picture again
+func (g *Game) resetCamera() {
+	g.camZoom = 1
+	g.camX, g.camY = 0, 0
+	g.idleDrawn = false
+}
+
+// cameraGeoM maps mainCanvas to the screen through the free camera
+func (g *Game) cameraGeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Scale(g.camZoom, g.camZoom)
+	m.Translate(g.camX, g.camY)
+	return m
+}
+
+// canvasCursor returns the cursor position on mainCanvas, undoing the
+// camera, so clicks land on what is shown under the pointer
+func (g *Game) canvasCursor() (x, y float64) {
+	x, y = g.cursorPosition()
+	return (x - g.camX) / g.camZoom, (y - g.camY) / g.camZoom
+}
@@ -0,0 +1,50 @@
+package demo
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Caption is a line of text shown from Ms milliseconds into the music until
+// the next caption. An empty Text clears the screen.
+type Caption struct {
+	Ms   int
+	Text string
+}
+
+// SetCaptions sets the subtitles shown during the demo, timed to the music
+func (g *Game) SetCaptions(captions []Caption) {
+	g.captions = append([]Caption(nil), captions...)
+	sort.SliceStable(g.captions, func(i, j int) bool {
+		return g.captions[i].Ms < g.captions[j].Ms
+	})
+}
+
+// currentCaption returns the caption for the music position, "" if none.
+// The captions are sorted, so this is a binary search.
+func (g *Game) currentCaption() string {
+	if len(g.captions) == 0 || g.music == nil {
+		return ""
+	}
+	ms := g.music.PositionMs()
+	i := sort.Search(len(g.captions), func(i int) bool {
+		return g.captions[i].Ms > ms
+	})
+	if i == 0 {
+		return ""
+	}
+	return g.captions[i-1].Text
+}
+
+// drawCaption draws the current caption centered near the bottom of dst,
+// above the banner when it is at the bottom
+func (g *Game) drawCaption(dst *ebiten.Image) {
+	text := g.currentCaption()
+	if text == "" {
+		return
+	}
+
+	bottom := g.playfieldTop() + g.height - g.bannerHeight
+	g.drawCentered(dst, text, float64(bottom-fontHeight-16), 1)
+}
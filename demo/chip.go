@@ -0,0 +1,84 @@
+package demo
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChipPlayer is a chiptune decoder streaming 16-bit stereo PCM for Ebiten
+// audio. YMPlayer is the only backend today; SNDH or AY dumps can be added by
+// implementing this interface and registering a constructor in chipBackends.
+type ChipPlayer interface {
+	io.ReadSeekCloser
+
+	// GetInfo describes the loaded tune
+	GetInfo() ChipInfo
+
+	// Duration is the length of one pass, 0 if unknown; IsLooping tells
+	// whether playback repeats it forever and SetLooping changes that. Ended
+	// reports that a tune that does not loop has been read to its end, until
+	// the next Seek.
+	Duration() time.Duration
+	IsLooping() bool
+	SetLooping(loop bool)
+	Ended() bool
+
+	// PositionMs is the decode position in milliseconds
+	PositionMs() int
+
+	// GetVolume and SetVolume control the output gain, 0..1
+	GetVolume() float64
+	SetVolume(vol float64)
+
+	// GetLevels returns the peak and RMS level of the last decoded audio
+	GetLevels() (peak, rms float64)
+
+	// ChannelLevels estimates the level of each of the three chip voices
+	ChannelLevels() [3]float64
+}
+
+// registerSnapshotter is implemented by backends that can report the chip
+// registers behind the audio, like YMPlayer
+type registerSnapshotter interface {
+	RegisterSnapshot() [16]byte
+}
+
+// statsReporter is implemented by backends that count the audio they could
+// not decode, like YMPlayer
+type statsReporter interface {
+	Stats() PlayerStats
+}
+
+// PlayerStats counts the gaps in a player's output, to diagnose crackle
+type PlayerStats struct {
+	Underruns    int   // chunks Read filled with silence as the decoder failed
+	SilentFrames int64 // stereo frames of that silence
+	EOFs         int   // Reads cut short by the end of a tune that does not loop
+}
+
+// ChipInfo describes a loaded tune
+type ChipInfo struct {
+	Format   string        // backend name, e.g. "YM"
+	Duration time.Duration // length of one pass through the tune, 0 if unknown
+}
+
+// chipBackends maps a lower-case file extension to the player able to decode it
+var chipBackends = map[string]func(data []byte, sampleRate int, loop bool) (ChipPlayer, error){
+	".ym": func(data []byte, sampleRate int, loop bool) (ChipPlayer, error) {
+		return NewYMPlayer(data, sampleRate, loop)
+	},
+}
+
+// NewChipPlayer creates a player for data, picking the backend from the
+// extension of name
+func NewChipPlayer(name string, data []byte, sampleRate int, loop bool) (ChipPlayer, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	newPlayer, ok := chipBackends[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported music format %q", ext)
+	}
+	return newPlayer(data, sampleRate, loop)
+}
@@ -0,0 +1,66 @@
+package demo
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Background of the demo behind the rotozoom, without a chroma key
+var defaultDemoBackground = color.RGBA{0x00, 0x00, 0x30, 0xff}
+
+// ParseChromaKey reads a key color: green, magenta, or #rrggbb
+func ParseChromaKey(name string) (color.RGBA, error) {
+	switch name {
+	case "green":
+		return color.RGBA{0x00, 0xff, 0x00, 0xff}, nil
+	case "magenta":
+		return color.RGBA{0xff, 0x00, 0xff, 0xff}, nil
+	}
+	if c, ok := parseHexColor(name); ok {
+		return c, nil
+	}
+	return color.RGBA{}, fmt.Errorf("unknown chroma key %q, want green, magenta or #rrggbb", name)
+}
+
+// parseHexColor reads an opaque #rrggbb color
+func parseHexColor(s string) (color.RGBA, bool) {
+	if hex, ok := strings.CutPrefix(s, "#"); ok && len(hex) == 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, true
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// SetChromaKey fills the background with key instead of black, in the intro
+// and the demo, so the frame can be keyed out over video afterwards. nil
+// goes back to the normal background.
+//
+// Only the background changes, and some effects assume it is dark. The
+// rotozoom covers the whole screen, so it has to be hidden for the key to
+// show (-layers, or key 1). The title banner is a black band behind its
+// copper bars (hide it with key 5), the translucent DMA logos blend into
+// the key where they overlap it, and the CRT look shades it with scanlines
+// and the vignette and leaves black corners: turn it off (C) for a clean
+// key.
+func (g *Game) SetChromaKey(key color.Color) {
+	g.chromaKey = key
+}
+
+// background returns the color the frame starts from
+func (g *Game) background() color.Color {
+	if g.chromaKey != nil {
+		return g.chromaKey
+	}
+	return color.Black
+}
+
+// demoBackground returns the color behind the demo effects
+func (g *Game) demoBackground() color.Color {
+	if g.chromaKey != nil {
+		return g.chromaKey
+	}
+	return defaultDemoBackground
+}
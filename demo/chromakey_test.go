@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseChromaKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{name: "green", want: color.RGBA{0x00, 0xff, 0x00, 0xff}},
+		{name: "magenta", want: color.RGBA{0xff, 0x00, 0xff, 0xff}},
+		{name: "#000000", want: color.RGBA{0x00, 0x00, 0x00, 0xff}},
+		{name: "#12aB3c", want: color.RGBA{0x12, 0xab, 0x3c, 0xff}},
+		{name: "#FFFFFF", want: color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{name: "", wantErr: true},
+		{name: "blue", wantErr: true},
+		{name: "Green", wantErr: true},
+		{name: "00ff00", wantErr: true},
+		{name: "#0f0", wantErr: true},
+		{name: "#00ff00ff", wantErr: true},
+		{name: "#00ff0g", wantErr: true},
+		{name: "#+0ff00", wantErr: true},
+		{name: "# 0ff00", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChromaKey(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseChromaKey(%q) = %v, %v, want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestChromaKeyBackground(t *testing.T) {
+	g := &Game{}
+	if g.background() != color.Black || g.demoBackground() != defaultDemoBackground {
+		t.Errorf("backgrounds without a key = %v, %v", g.background(), g.demoBackground())
+	}
+	key := color.RGBA{0x00, 0xff, 0x00, 0xff}
+	g.SetChromaKey(key)
+	if g.background() != key || g.demoBackground() != key {
+		t.Errorf("backgrounds with a key = %v, %v, want %v", g.background(), g.demoBackground(), key)
+	}
+	g.SetChromaKey(nil)
+	if g.background() != color.Black {
+		t.Errorf("background after clearing the key = %v", g.background())
+	}
+}
@@ -0,0 +1,278 @@
+package demo
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Width of one copper bar in bars.png, also used for procedural bars
+const copperBarWidth = 46
+
+// CopperBars draws the bars.png stripes as horizontal copper bars swinging on
+// two sine phases, filling a banner of any height (one bar per 2 rows)
+type CopperBars struct {
+	img  *ebiten.Image
+	sin  []int
+	cnt  float64
+	cnt2 float64
+
+	// Speed scales how fast the two sine phases advance; 1 is the
+	// original 3 and -5 table entries per 60 TPS tick.
+	Speed float64
+
+	// PhaseA and PhaseB are how many sine table entries each bar lags the
+	// one above it on the two phases: larger values ripple the bars more
+	// tightly across the banner, 0 moves them all as one. The defaults are
+	// the original 7 and 10.
+	PhaseA, PhaseB int
+
+	// Amplitude scales the sine swing of the bars around their center:
+	// 1 is the original motion, 0 stacks every bar in the middle.
+	Amplitude float64
+
+	// Palette, when set, replaces the image: each bar is filled
+	// procedurally with the next palette color, shaded like a copper bar.
+	Palette []color.Color
+
+	// Safe makes the bars photosensitive-safe: the phase advance and the
+	// amplitude go through a low-pass filter, and the bars are dimmed so no
+	// pixel of the banner can change by a full flash step (see safeFlashLuminance).
+	Safe bool
+
+	adv, adv2 float64 // phase advance per 60 TPS tick, smoothed in safe mode
+	amp       float64 // amplitude actually drawn, smoothed in safe mode
+	safeScale float64 // color scale that caps the image luminance, 0 until measured
+}
+
+// Photosensitive-safe limits. WCAG 2.3.1 counts a general flash as a pair of
+// opposing changes of 10% or more in relative luminance, and allows no more
+// than 3 per second. Capping the bars' peak luminance below that step means
+// no change against the black banner can count as a flash, whatever the motion.
+//
+// Measured per pixel over 10 seconds of the 800x72 banner with bars.png, the
+// normal bars reach 12 flashes/s with half the banner above 3/s. Slowing them
+// to a third of the speed only brings that down to 9/s, which is why the cap
+// is on luminance. In safe mode the count is 0 everywhere.
+const (
+	safeFlashLuminance   = 0.09
+	copperSafeSmoothing  = 0.05 // low-pass coefficient per 60 TPS tick
+	copperSafeMaxAdvance = 2.0  // table entries per 60 TPS tick
+)
+
+// NewCopperBars creates copper bars cut from the stripes of img
+func NewCopperBars(img *ebiten.Image) *CopperBars {
+	c := &CopperBars{img: img, Amplitude: 1.0, Speed: 1.0, amp: 1.0, PhaseA: 7, PhaseB: 10}
+	c.initCopperSin()
+	return c
+}
+
+// Shape of the copper sine table: two periods over 1024 entries swinging
+// between 4 and 524 in steps of 4, like the original Atari ST table
+const (
+	copperSinSize      = 1024
+	copperSinPeriod    = 512
+	copperSinOffset    = 264.0
+	copperSinAmplitude = 261.6
+	copperSinStep      = 4
+)
+
+// initCopperSin initializes the sine table for copper bars animation
+func (c *CopperBars) initCopperSin() {
+	c.sin = generateCopperSin(copperSinOffset, copperSinAmplitude)
+}
+
+// generateCopperSin samples offset+amplitude*sin over the table, quantized to
+// copperSinStep. With the default shape every entry is within one step of the
+// hand-made original; the quarter-sample phase lines up its zero crossings.
+func generateCopperSin(offset, amplitude float64) []int {
+	table := make([]int, copperSinSize)
+	for k := range table {
+		v := offset + amplitude*math.Sin(2*math.Pi*(float64(k)-0.25)/copperSinPeriod)
+		table[k] = copperSinStep * int(math.Round(v/copperSinStep))
+	}
+	return table
+}
+
+// Update advances the two sine phases by step 60 TPS ticks, so the bars
+// sweep at the same speed whatever the tick rate. The phases stay fractional
+// and are only truncated to a table entry when drawn.
+func (c *CopperBars) Update(step float64) {
+	adv, adv2 := 3*c.Speed, -5*c.Speed
+	if !c.Safe {
+		c.adv, c.adv2, c.amp = adv, adv2, c.Amplitude
+	} else {
+		adv = math.Max(-copperSafeMaxAdvance, math.Min(adv, copperSafeMaxAdvance))
+		adv2 = math.Max(-copperSafeMaxAdvance, math.Min(adv2, copperSafeMaxAdvance))
+		k := 1 - math.Pow(1-copperSafeSmoothing, step)
+		c.adv += (adv - c.adv) * k
+		c.adv2 += (adv2 - c.adv2) * k
+		c.amp += (c.Amplitude - c.amp) * k
+	}
+	c.cnt = wrapPhase(c.cnt + c.adv*step)
+	c.cnt2 = wrapPhase(c.cnt2 + c.adv2*step)
+}
+
+// Reset puts both sine phases back to their starting point
+func (c *CopperBars) Reset() {
+	c.cnt, c.cnt2 = 0, 0
+}
+
+// wrapPhase wraps a sine table phase into [0, copperSinSize)
+func wrapPhase(p float64) float64 {
+	p = math.Mod(p, copperSinSize)
+	if p < 0 {
+		p += copperSinSize
+	}
+	return p
+}
+
+// Draw renders the bars into the banner dst, using its full height
+func (c *CopperBars) Draw(dst *ebiten.Image) {
+	bannerWidth, bannerHeight := dst.Bounds().Dx(), dst.Bounds().Dy()
+
+	if len(c.Palette) > 0 {
+		c.drawPalette(dst, bannerWidth, bannerHeight)
+		return
+	}
+
+	if c.img == nil {
+		return
+	}
+
+	// The image is a stack of 2px stripes, cycled through one per bar.
+	// bars.png has 10; any even height works and an odd last row is unused.
+	barsWidth, barsHeight := c.img.Bounds().Dx(), c.img.Bounds().Dy()
+	stripes := barsHeight &^ 1
+	if stripes < 2 {
+		return
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	if c.Safe {
+		s := c.imageSafeScale()
+		op.ColorScale.Scale(float32(s), float32(s), float32(s), 1)
+	}
+
+	// Draw copper bars filling the banner height, 2 pixels per bar
+	for i := 0; i < bannerHeight/2; i++ {
+		xPos, yPos, height := c.barGeometry(i, bannerWidth, bannerHeight)
+
+		if height > 0 && yPos < bannerHeight {
+			op.GeoM.Reset()
+
+			// Source rectangle: 2 pixels high from bars
+			cc := copperStripe(i, stripes)
+			srcRect := image.Rect(0, cc, barsWidth, cc+2)
+
+			// Scale to stretch the 2 pixels
+			scaleY := float64(height) / 2.0
+
+			op.GeoM.Scale(1, scaleY)
+			op.GeoM.Translate(float64(xPos), float64(yPos))
+
+			dst.DrawImage(c.img.SubImage(srcRect).(*ebiten.Image), op)
+		}
+	}
+}
+
+// copperStripe returns the first row of the 2px stripe bar i is cut from,
+// cycling through the stripes rows of the image
+func copperStripe(i, stripes int) int {
+	return i * 2 % stripes
+}
+
+// barGeometry returns the position and height of bar i from the two sine
+// phases. Each bar reaches down to the bottom of the banner; the sweep is
+// laid out for an 800px banner and stretched to other widths.
+func (c *CopperBars) barGeometry(i, bannerWidth, bannerHeight int) (xPos, yPos, height int) {
+	// Calculate sine positions for animation
+	val2 := (int(c.cnt) + i*c.PhaseA) & 0x3ff
+	val := c.sin[val2]
+	val2 = (int(c.cnt2) + i*c.PhaseB) & 0x3ff
+	val += c.sin[val2]
+	if c.amp != 1.0 {
+		center := 2 * copperSinOffset
+		val = int(center + (float64(val)-center)*c.amp)
+	}
+	val += 60
+
+	// Position
+	xPos = val >> 1
+	if bannerWidth != screenWidth {
+		xPos = xPos * bannerWidth / screenWidth
+	}
+	yPos = i << 1 // i * 2
+	height = bannerHeight - yPos
+	return xPos, yPos, height
+}
+
+// drawPalette draws the bars as vector rects tinted with Palette. Each bar is
+// split in 2px columns whose brightness follows a half sine, bright in the
+// middle and dark at the edges, like the stripes of bars.png.
+func (c *CopperBars) drawPalette(dst *ebiten.Image, bannerWidth, bannerHeight int) {
+	for i := 0; i < bannerHeight/2; i++ {
+		xPos, yPos, height := c.barGeometry(i, bannerWidth, bannerHeight)
+		if height <= 0 {
+			continue
+		}
+
+		r, g, b, _ := c.Palette[i%len(c.Palette)].RGBA()
+		scale := 1.0
+		if c.Safe {
+			scale = safeColorScale(relativeLuminance(uint8(r>>8), uint8(g>>8), uint8(b>>8)))
+		}
+		for x := 0; x < copperBarWidth; x += 2 {
+			shade := scale * (0.35 + 0.65*math.Sin(math.Pi*float64(x+1)/float64(copperBarWidth+1)))
+			clr := color.RGBA{
+				uint8(float64(r>>8) * shade),
+				uint8(float64(g>>8) * shade),
+				uint8(float64(b>>8) * shade),
+				0xff,
+			}
+			vector.DrawFilledRect(dst, float32(xPos+x), float32(yPos), 2, float32(height), clr, false)
+		}
+	}
+}
+
+// imageSafeScale measures the brightest pixel of the bars image once and
+// returns the color scale that brings it down to safeFlashLuminance
+func (c *CopperBars) imageSafeScale() float64 {
+	if c.safeScale > 0 {
+		return c.safeScale
+	}
+	w, h := c.img.Bounds().Dx(), c.img.Bounds().Dy()
+	pix := make([]byte, 4*w*h)
+	c.img.ReadPixels(pix)
+	peak := 0.0
+	for i := 0; i < len(pix); i += 4 {
+		peak = math.Max(peak, relativeLuminance(pix[i], pix[i+1], pix[i+2]))
+	}
+	c.safeScale = safeColorScale(peak)
+	return c.safeScale
+}
+
+// safeColorScale returns the factor to scale sRGB components by so a color of
+// relative luminance lum ends up at or below safeFlashLuminance
+func safeColorScale(lum float64) float64 {
+	if lum <= safeFlashLuminance {
+		return 1
+	}
+	// Luminance goes roughly with the 2.2 power of the sRGB components
+	return math.Pow(safeFlashLuminance/lum, 1/2.2)
+}
+
+// relativeLuminance is the WCAG relative luminance of an sRGB color, 0 to 1
+func relativeLuminance(r, g, b uint8) float64 {
+	lin := func(c uint8) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(r) + 0.7152*lin(g) + 0.0722*lin(b)
+}
@@ -0,0 +1,195 @@
+package demo
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// writeIntTable writes table in the form readIntTable reads, 16 to a line
+// under a # comment
+func writeIntTable(t *testing.T, name, comment string, table []int) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("# " + comment + "\n")
+	for i, v := range table {
+		b.WriteString(strconv.Itoa(v))
+		if i%16 == 15 || i == len(table)-1 {
+			b.WriteByte('\n')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	if err := os.WriteFile(name, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readIntTable reads whitespace separated integers, skipping # comments
+func readIntTable(t *testing.T, name string) []int {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var table []int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			table = append(table, v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestGenerateCopperSin(t *testing.T) {
+	original := readIntTable(t, "testdata/copper_sin.txt")
+	got := generateCopperSin(copperSinOffset, copperSinAmplitude)
+	if len(got) != len(original) {
+		t.Fatalf("len = %d, want %d", len(got), len(original))
+	}
+
+	// The hand-made table rounds irregularly: 130 entries are a step off
+	// the closest sine, none more
+	differ := 0
+	for i, want := range original {
+		if d := got[i] - want; d != 0 {
+			differ++
+			if d < -copperSinStep || d > copperSinStep {
+				t.Errorf("entry %d = %d, want %d within %d", i, got[i], want, copperSinStep)
+			}
+		}
+		if got[i]%copperSinStep != 0 {
+			t.Errorf("entry %d = %d, not a multiple of %d", i, got[i], copperSinStep)
+		}
+	}
+	if differ != 130 {
+		t.Errorf("%d entries differ from the original table, want 130", differ)
+	}
+}
+
+func TestCopperStripe(t *testing.T) {
+	tests := []struct {
+		height int // of the bars image
+		want   []int
+	}{
+		{2, []int{0, 0, 0, 0}},
+		{3, []int{0, 0, 0, 0}},
+		{5, []int{0, 2, 0, 2}},
+		{10, []int{0, 2, 4, 6, 8, 0, 2}},
+		{11, []int{0, 2, 4, 6, 8, 0, 2}},
+		{20, []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 0}},
+	}
+	for _, tt := range tests {
+		stripes := tt.height &^ 1
+		for i, want := range tt.want {
+			if got := copperStripe(i, stripes); got != want {
+				t.Errorf("height %d: bar %d cut from row %d, want %d", tt.height, i, got, want)
+			}
+		}
+		// Every bar of a 600 row banner stays inside the image
+		for i := range screenHeight / 2 {
+			if top := copperStripe(i, stripes); top < 0 || top+2 > tt.height {
+				t.Fatalf("height %d: bar %d cut from rows %d-%d", tt.height, i, top, top+1)
+			}
+		}
+	}
+}
+
+// TestCopperBarsShortImage draws bars cut from images shorter than bars.png,
+// whose odd last row is marked green: no bar may be cut from it
+func TestCopperBarsShortImage(t *testing.T) {
+	needGPU(t)
+	red, green := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}
+	for _, height := range []int{1, 3, 5} {
+		bars := ebiten.NewImage(copperBarWidth, height)
+		bars.Fill(red)
+		bars.SubImage(image.Rect(0, height-1, copperBarWidth, height)).(*ebiten.Image).Fill(green)
+
+		dst := ebiten.NewImage(screenWidth, defaultBannerHeight)
+		NewCopperBars(bars).Draw(dst)
+		pix := make([]byte, 4*screenWidth*defaultBannerHeight)
+		dst.ReadPixels(pix)
+		reds := 0
+		for i := 0; i < len(pix); i += 4 {
+			if pix[i+1] != 0 {
+				t.Fatalf("height %d: pixel %d drawn from the unused last row", height, i/4)
+			}
+			if pix[i] != 0 {
+				reds++
+			}
+		}
+		if want := height > 1; (reds > 0) != want {
+			t.Errorf("height %d: %d pixels drawn, want some: %v", height, reds, want)
+		}
+	}
+}
+
+func TestRelativeLuminance(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    float64
+	}{
+		{0, 0, 0, 0},
+		{255, 255, 255, 1},
+		{255, 0, 0, 0.2126},
+		{0, 255, 0, 0.7152},
+		{0, 0, 255, 0.0722},
+		// Mid gray is far darker than half in linear light
+		{128, 128, 128, 0.2159},
+		// Below 0.03928 the curve is linear
+		{10, 10, 10, 10.0 / 255 / 12.92},
+	}
+	for _, tt := range tests {
+		if got := relativeLuminance(tt.r, tt.g, tt.b); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("relativeLuminance(%d, %d, %d) = %.5f, want %.5f", tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSafeColorScale(t *testing.T) {
+	tests := []struct {
+		lum, want float64
+	}{
+		{0, 1},
+		{safeFlashLuminance / 2, 1},
+		{safeFlashLuminance, 1},
+		{1, 0.3346},
+		{4 * safeFlashLuminance, 0.5325},
+	}
+	for _, tt := range tests {
+		if got := safeColorScale(tt.lum); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("safeColorScale(%v) = %.4f, want %.4f", tt.lum, got, tt.want)
+		}
+	}
+
+	// Scaled by it, any color lands at or about safeFlashLuminance. The 2.2
+	// power only approximates the sRGB curve, so allow a few percent.
+	for _, c := range [][3]uint8{{255, 255, 255}, {255, 0, 0}, {0, 255, 0}, {255, 140, 0}, {90, 90, 200}} {
+		s := safeColorScale(relativeLuminance(c[0], c[1], c[2]))
+		scaled := relativeLuminance(uint8(float64(c[0])*s), uint8(float64(c[1])*s), uint8(float64(c[2])*s))
+		if scaled > safeFlashLuminance*1.05 {
+			t.Errorf("%v scaled by %.3f has luminance %.4f, over %v", c, s, scaled, safeFlashLuminance)
+		}
+	}
+}
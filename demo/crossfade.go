@@ -0,0 +1,243 @@
+package demo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Length of a track switch in seconds
+const crossfadeSeconds = 1.0
+
+// Crossfader is a ChipPlayer playing one of two tracks, switching between
+// them with an equal-power crossfade. Only the audible tracks are decoded:
+// outside a fade the silent one is not read, so it resumes where it was
+// left when switched back to. Everything but the volume describes the track
+// being faded to.
+type Crossfader struct {
+	mutex   sync.Mutex
+	tracks  [2]ChipPlayer
+	target  int     // track being faded to
+	mix     float64 // 0 = tracks[0] only, 1 = tracks[1] only
+	step    float64 // mix change per stereo frame
+	bufs    [2][]byte
+	lens    [2]int  // bytes read into bufs, the unmixed ones kept for the next Read
+	frame   [4]byte // last mixed frame, when split across Read calls
+	pending []byte  // unread tail of frame
+}
+
+// NewCrossfader plays a, switching to b and back with Switch
+func NewCrossfader(a, b ChipPlayer, sampleRate int) *Crossfader {
+	return &Crossfader{
+		tracks: [2]ChipPlayer{a, b},
+		step:   1 / (crossfadeSeconds * float64(sampleRate)),
+	}
+}
+
+var _ ChipPlayer = (*Crossfader)(nil)
+
+// Switch starts fading to the other track. Switching again mid-fade turns
+// the fade around from where it is.
+func (x *Crossfader) Switch() {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.target = 1 - x.target
+}
+
+// Track returns the index of the track playing or being faded to
+func (x *Crossfader) Track() int {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.target
+}
+
+// Read mixes the tracks into 16-bit stereo PCM. The tracks are always read
+// whole frames at a time so their samples stay aligned. When one track
+// gives fewer bytes than the other, the other's extra bytes are kept and
+// mixed first on the next Read rather than dropped.
+func (x *Crossfader) Read(p []byte) (int, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	n := copy(p, x.pending)
+	x.pending = x.pending[n:]
+	p = p[n:]
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	size := (len(p) + 3) &^ 3
+	var heard [2]bool
+	for i, track := range x.tracks {
+		if heard[i] = x.audible(i); !heard[i] {
+			continue
+		}
+		if len(x.bufs[i]) < size {
+			buf := make([]byte, size)
+			copy(buf, x.bufs[i][:x.lens[i]])
+			x.bufs[i] = buf
+		}
+		if x.lens[i] >= size {
+			continue
+		}
+		got, err := x.readTrack(i, track, x.bufs[i][x.lens[i]:size])
+		x.lens[i] += got
+		if err != nil && i == x.target {
+			// The track we are heading to has ended: play out what is left
+			size = min(size, x.lens[i]&^3)
+			if size == 0 {
+				return n, err
+			}
+		}
+	}
+
+	for off := 0; off+4 <= size; off += 4 {
+		// Equal-power gains keep the loudness steady through the fade
+		g0, g1 := math.Cos(x.mix*math.Pi/2), math.Sin(x.mix*math.Pi/2)
+		var l, r float64
+		if heard[0] && off+4 <= x.lens[0] {
+			l += g0 * float64(int16(binary.LittleEndian.Uint16(x.bufs[0][off:])))
+			r += g0 * float64(int16(binary.LittleEndian.Uint16(x.bufs[0][off+2:])))
+		}
+		if heard[1] && off+4 <= x.lens[1] {
+			l += g1 * float64(int16(binary.LittleEndian.Uint16(x.bufs[1][off:])))
+			r += g1 * float64(int16(binary.LittleEndian.Uint16(x.bufs[1][off+2:])))
+		}
+		binary.LittleEndian.PutUint16(x.frame[:], uint16(clampSample(l)))
+		binary.LittleEndian.PutUint16(x.frame[2:], uint16(clampSample(r)))
+
+		if x.mix < float64(x.target) {
+			x.mix = math.Min(x.mix+x.step, 1)
+		} else if x.mix > float64(x.target) {
+			x.mix = math.Max(x.mix-x.step, 0)
+		}
+
+		k := copy(p[off:], x.frame[:])
+		if k < 4 {
+			x.pending = x.frame[k:]
+		}
+	}
+
+	// Keep what a track gave past the mixed frames for the next Read
+	for i := range x.lens {
+		if heard[i] {
+			used := min(size, x.lens[i])
+			x.lens[i] = copy(x.bufs[i], x.bufs[i][used:x.lens[i]])
+		}
+	}
+	return n + min(size, len(p)), nil
+}
+
+// audible reports whether track i is heard, or about to be
+func (x *Crossfader) audible(i int) bool {
+	if i == x.target {
+		return true
+	}
+	if i == 0 {
+		return x.mix < 1
+	}
+	return x.mix > 0
+}
+
+// readTrack fills buf from track i. A track fading out that ends is
+// restarted, so a shorter tune loops under the fade rather than cutting out.
+func (x *Crossfader) readTrack(i int, track ChipPlayer, buf []byte) (int, error) {
+	got, err := io.ReadFull(track, buf)
+	if err == nil || i == x.target {
+		return got, err
+	}
+	if _, err := track.Seek(0, io.SeekStart); err != nil {
+		return got, err
+	}
+	more, err := io.ReadFull(track, buf[got:])
+	return got + more, err
+}
+
+// clampSample rounds a mixed sample into the int16 range
+func clampSample(v float64) int16 {
+	return int16(math.Max(math.MinInt16, math.Min(math.Round(v), math.MaxInt16)))
+}
+
+// Seek moves the track being played, in bytes like Read
+func (x *Crossfader) Seek(offset int64, whence int) (int64, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	// The bytes read ahead of either track are from before the seek
+	x.pending = nil
+	x.lens = [2]int{}
+	return x.tracks[x.target].Seek(offset, whence)
+}
+
+// Close closes both tracks
+func (x *Crossfader) Close() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return errors.Join(x.tracks[0].Close(), x.tracks[1].Close())
+}
+
+// current returns the track being played or faded to
+func (x *Crossfader) current() ChipPlayer {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.tracks[x.target]
+}
+
+// The description, position and levels are those of the current track
+func (x *Crossfader) GetInfo() ChipInfo              { return x.current().GetInfo() }
+func (x *Crossfader) Duration() time.Duration        { return x.current().Duration() }
+func (x *Crossfader) IsLooping() bool                { return x.current().IsLooping() }
+func (x *Crossfader) Ended() bool                    { return x.current().Ended() }
+func (x *Crossfader) PositionMs() int                { return x.current().PositionMs() }
+func (x *Crossfader) GetLevels() (peak, rms float64) { return x.current().GetLevels() }
+func (x *Crossfader) ChannelLevels() [3]float64      { return x.current().ChannelLevels() }
+
+// GetVolume returns the volume both tracks play at
+func (x *Crossfader) GetVolume() float64 {
+	return x.tracks[0].GetVolume()
+}
+
+// SetVolume sets the volume of both tracks
+func (x *Crossfader) SetVolume(vol float64) {
+	x.tracks[0].SetVolume(vol)
+	x.tracks[1].SetVolume(vol)
+}
+
+// SetLooping sets the loop mode of both tracks
+func (x *Crossfader) SetLooping(loop bool) {
+	x.tracks[0].SetLooping(loop)
+	x.tracks[1].SetLooping(loop)
+}
+
+// SetReverse plays both tracks backward while on, when they can
+func (x *Crossfader) SetReverse(on bool) {
+	for _, track := range x.tracks {
+		if r, ok := track.(reverser); ok {
+			r.SetReverse(on)
+		}
+	}
+}
+
+// Stats adds up the counts of both tracks, as both are read during a fade
+func (x *Crossfader) Stats() PlayerStats {
+	var total PlayerStats
+	for _, track := range x.tracks {
+		if r, ok := track.(statsReporter); ok {
+			s := r.Stats()
+			total.Underruns += s.Underruns
+			total.SilentFrames += s.SilentFrames
+			total.EOFs += s.EOFs
+		}
+	}
+	return total
+}
+
+// RegisterSnapshot forwards to the current track when it exposes registers
+func (x *Crossfader) RegisterSnapshot() [16]byte {
+	if snap, ok := x.current().(registerSnapshotter); ok {
+		return snap.RegisterSnapshot()
+	}
+	return [16]byte{}
+}
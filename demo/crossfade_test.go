@@ -0,0 +1,126 @@
+package demo
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+// fadeToEnd fades from a long ramp to a short silent tune that ends after
+// the first Read of 5000 frames, and returns the crossfader with that Read
+func fadeToEnd(t *testing.T) (*Crossfader, []int16, error) {
+	t.Helper()
+	short := newFakeTune(300)
+	short.sample = func(int) int16 { return 0 }
+	x := NewCrossfader(newTestPlayer(t, newFakeTune(50000), true), newTestPlayer(t, short, false), fakeRate)
+	x.Switch()
+
+	p := make([]byte, 5000*4)
+	n, err := x.Read(p)
+	out := make([]int16, n/4)
+	for i := range out {
+		out[i] = int16(uint16(p[i*4]) | uint16(p[i*4+1])<<8)
+	}
+	return x, out, err
+}
+
+// fadeOutSample is frame k of the long ramp fading out from the start
+func fadeOutSample(k int) float64 {
+	mix := math.Min(float64(k)/fakeRate/crossfadeSeconds, 1)
+	return math.Cos(mix*math.Pi/2) * float64(rampSample(k))
+}
+
+// TestCrossfaderTrackEnds fades to a tune that ends mid-read: the Read plays
+// out the frames the tune still gave, mixed with the track fading out
+func TestCrossfaderTrackEnds(t *testing.T) {
+	// The short tune pads one 4096 frame Compute call with silence
+	_, out, err := fadeToEnd(t)
+	if len(out) != 4096 || err != nil {
+		t.Fatalf("Read = %d frames, %v, want 4096 frames", len(out), err)
+	}
+	for k, got := range out {
+		if want := fadeOutSample(k); math.Abs(float64(got)-want) > 1 {
+			t.Fatalf("frame %d = %d, want %.0f", k, got, want)
+		}
+	}
+}
+
+// TestCrossfaderSeek seeks after a Read that left frames of the old track
+// unmixed. They are from before the seek, so switching back must not play
+// them: the old track carries on from where it was read to.
+func TestCrossfaderSeek(t *testing.T) {
+	x, _, _ := fadeToEnd(t)
+	if _, err := x.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	x.Switch()
+
+	p := make([]byte, 1000*4)
+	if n, err := x.Read(p); n != len(p) || err != nil {
+		t.Fatalf("Read = %d, %v", n, err)
+	}
+	// Halfway back the old track is at sqrt(1/2), 5000 frames in
+	const k = 500
+	got := float64(int16(uint16(p[k*4]) | uint16(p[k*4+1])<<8))
+	mix := 1 - float64(k)/fakeRate/crossfadeSeconds
+	if want := math.Cos(mix*math.Pi/2) * float64(rampSample(5000+k)); math.Abs(got-want) > 1 {
+		t.Errorf("frame %d after switching back = %.0f, want %.0f", k, got, want)
+	}
+}
+
+// TestCrossfaderOddReads reads a fade in sizes that split the stereo frames
+// and checks it matches one read in a single call
+func TestCrossfaderOddReads(t *testing.T) {
+	read := func(sizes []int) []byte {
+		x := NewCrossfader(newTestPlayer(t, newFakeTune(50000), true), newTestPlayer(t, newFakeTune(700), true), fakeRate)
+		x.Switch()
+		var all []byte
+		for _, size := range sizes {
+			p := make([]byte, size)
+			n, err := x.Read(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, p[:n]...)
+		}
+		return all
+	}
+	whole := read([]int{6000})
+	split := read([]int{1, 2, 999, 7, 3000, 1991})
+	if string(whole) != string(split) {
+		t.Error("reading in odd sizes gives different audio than one read")
+	}
+}
+
+// TestCrossfaderYMTracks fades between two YM players decoding the
+// built-in tune from different places and back, checking the mix is sound
+// the whole way
+func TestCrossfaderYMTracks(t *testing.T) {
+	const rate = 44100
+	var tracks [2]ChipPlayer
+	for i := range tracks {
+		y, err := NewYMPlayer(loadAsset(t, "mindbomb.ym"), rate, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tracks[i] = y
+	}
+	if _, err := tracks[1].Seek(30*rate*4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	x := NewCrossfader(tracks[0], tracks[1], rate)
+	defer x.Close()
+
+	p := make([]byte, rate/2*4)
+	for half := range 8 {
+		if half == 2 || half == 6 {
+			x.Switch()
+		}
+		if n, err := x.Read(p); n != len(p) || err != nil {
+			t.Fatalf("half second %d: Read = %d, %v", half, n, err)
+		}
+		if _, rms := x.GetLevels(); rms == 0 {
+			t.Errorf("half second %d: track %d is silent", half, x.Track())
+		}
+	}
+}
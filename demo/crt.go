@@ -0,0 +1,100 @@
+package demo
+
+// CRT Shader
+const crtShaderSrc = `
+package main
+
+// ScanlineCount is the number of dark/light line pairs over the source
+// height, set from the output resolution so lines don't shimmer on resize
+var ScanlineCount float
+
+// RGBShift is the horizontal offset of the red and blue channels, as a
+// fraction of the source width
+var RGBShift float
+
+// VignetteStrength is how much the corners darken, 0 for none
+var VignetteStrength float
+
+func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+	// Normalize to the source region so the math holds whatever the
+	// window size is and wherever the source sits in the atlas
+	var origin vec2
+	var size vec2
+	origin = imageSrc0Origin()
+	size = imageSrc0Size()
+
+	var uv vec2
+	uv = (texCoord - origin) / size
+
+	// Barrel distortion
+	var dc vec2
+	dc = uv - 0.5
+	dc = dc * (1.0 + dot(dc, dc) * 0.15)
+	uv = dc + 0.5
+
+	if uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0 {
+		return vec4(0.0, 0.0, 0.0, 1.0)
+	}
+
+	var col vec4
+	col = imageSrc0At(uv*size + origin)
+
+	// Scanlines
+	var scanline float
+	scanline = sin(uv.y * ScanlineCount * 6.2831853) * 0.04
+	col.rgb = col.rgb - scanline
+
+	// RGB shift
+	var rShift float
+	var bShift float
+	rShift = imageSrc0At((uv+vec2(RGBShift, 0.0))*size + origin).r
+	bShift = imageSrc0At((uv-vec2(RGBShift, 0.0))*size + origin).b
+	col.r = rShift
+	col.b = bShift
+
+	// Vignette
+	var vignette float
+	vignette = 1.0 - dot(dc, dc) * VignetteStrength
+	col.rgb = col.rgb * vignette
+
+	return col * color
+}
+`
+
+// Default strength of the CRT vignette, and how far the keys can push it
+const (
+	defaultVignette = 0.5
+	maxVignette     = 2.0
+	vignetteStep    = 0.1
+)
+
+// crtOptions are the uniforms of the CRT shader
+type crtOptions struct {
+	ScanlineCount    float32 // dark/light line pairs over the source height
+	RGBShift         float32 // red/blue offset, fraction of the source width
+	VignetteStrength float32 // corner darkening, 0 for a flat image
+}
+
+// uniforms returns the options in the form DrawRectShaderOptions takes
+func (o crtOptions) uniforms() map[string]any {
+	return map[string]any{
+		"ScanlineCount":    o.ScanlineCount,
+		"RGBShift":         o.RGBShift,
+		"VignetteStrength": o.VignetteStrength,
+	}
+}
+
+// crtOptions returns the CRT shader settings for a source h pixels high
+func (g *Game) crtOptions(h int) crtOptions {
+	return crtOptions{
+		ScanlineCount:    g.scanlineCount(h),
+		RGBShift:         g.rgbShift(),
+		VignetteStrength: float32(g.vignette),
+	}
+}
+
+// SetVignette sets how much the CRT look darkens the corners, from 0 (none,
+// a flat image for captures) to 2; the default is 0.5
+func (g *Game) SetVignette(strength float64) {
+	g.vignette = max(0, min(strength, maxVignette))
+}
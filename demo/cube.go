@@ -0,0 +1,457 @@
+package demo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	defaultFOV = 200.0
+	minFOV     = 80.0
+	maxFOV     = 800.0
+)
+
+// Cube3D represents a 3D cube
+type Cube3D struct {
+	angleX float64
+	angleY float64
+	angleZ float64
+	size   float64
+
+	// Rotation per tick around each axis, in radians, see SetSpin
+	spinX, spinY, spinZ float64
+
+	// Perspective is the camera distance used by project3D. Smaller values
+	// exaggerate depth (wide angle), larger ones flatten it (telephoto).
+	Perspective float64
+
+	// Antialias fills faces as antialiased vector paths instead of the
+	// manual 1px scanline spans. Smoother edges, but each face costs a path
+	// triangulation and an offscreen stencil pass.
+	Antialias bool
+
+	// Wireframe skips the face fill and only strokes the edges, still in
+	// back-to-front order
+	Wireframe bool
+
+	// FaceAlpha is the opacity of the filled faces, from 0 (invisible) to 1
+	// (opaque, the default). Edges stay opaque. There is no backface culling:
+	// all six faces are drawn back to front, which is what lets the back
+	// faces show through translucent front ones. Any culling added later
+	// must be skipped when FaceAlpha < 1.
+	FaceAlpha float64
+
+	// Palette colors the faces, one color per face in the order Draw
+	// lists them, repeating when shorter: a single color makes a monochrome
+	// cube. nil uses defaultCubePalette. The edges are the face colors
+	// darkened to three quarters.
+	Palette []color.Color
+
+	// Reused every frame for the painter's sort
+	depths []faceDepth
+
+	// Reused for antialiased face fills
+	path     vector.Path
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+// faceDepth is a face index with its average Z, used for sorting
+type faceDepth struct {
+	index int
+	depth float64
+}
+
+func NewCube3D(size float64) *Cube3D {
+	return &Cube3D{
+		size:        size,
+		Perspective: defaultFOV,
+		FaceAlpha:   1.0,
+	}
+}
+
+// Size returns the edge length of the cube
+func (c *Cube3D) Size() float64 {
+	return c.size
+}
+
+// SetSize changes the edge length of the cube. It can change every frame;
+// the corners are computed from it on each draw.
+func (c *Cube3D) SetSize(size float64) {
+	c.size = max(size, 1)
+}
+
+func (c *Cube3D) Rotate(dx, dy, dz float64) {
+	c.angleX += dx
+	c.angleY += dy
+	c.angleZ += dz
+}
+
+// SetSpin sets how far the cube turns around each axis per tick, in radians
+func (c *Cube3D) SetSpin(dx, dy, dz float64) {
+	c.spinX, c.spinY, c.spinZ = dx, dy, dz
+}
+
+// Spin advances the rotation by scale ticks of the spin set with SetSpin
+func (c *Cube3D) Spin(scale float64) {
+	c.Rotate(c.spinX*scale, c.spinY*scale, c.spinZ*scale)
+}
+
+// minProjectDepth keeps points pushed toward the camera from dividing by zero
+// or flipping behind it
+const minProjectDepth = 1.0
+
+// project3D projects 3D coordinates to 2D with the given camera distance
+func project3D(x, y, z, perspective float64) (float64, float64) {
+	depth := perspective + z
+	if depth < minProjectDepth {
+		depth = minProjectDepth
+	}
+	factor := perspective / depth
+	return x * factor, y * factor
+}
+
+// ProjectedVertices returns where the eight corners of the cube land on
+// screen when it is drawn centered on centerX, centerY, in the order Draw
+// uses: the four corners at z = -size/2 clockwise from the top left one,
+// then the four at z = +size/2 likewise
+func (c *Cube3D) ProjectedVertices(centerX, centerY float64) [][2]float64 {
+	return c.project(c.rotatedVertices(), centerX, centerY)
+}
+
+// rotatedVertices returns the cube corners turned by the current angles,
+// around X, then Y, then Z
+func (c *Cube3D) rotatedVertices() [][3]float64 {
+	// Define cube vertices in 3D space
+	vertices := [][3]float64{
+		{-c.size / 2, -c.size / 2, -c.size / 2}, // 0
+		{c.size / 2, -c.size / 2, -c.size / 2},  // 1
+		{c.size / 2, c.size / 2, -c.size / 2},   // 2
+		{-c.size / 2, c.size / 2, -c.size / 2},  // 3
+		{-c.size / 2, -c.size / 2, c.size / 2},  // 4
+		{c.size / 2, -c.size / 2, c.size / 2},   // 5
+		{c.size / 2, c.size / 2, c.size / 2},    // 6
+		{-c.size / 2, c.size / 2, c.size / 2},   // 7
+	}
+
+	cosX, sinX := math.Cos(c.angleX), math.Sin(c.angleX)
+	cosY, sinY := math.Cos(c.angleY), math.Sin(c.angleY)
+	cosZ, sinZ := math.Cos(c.angleZ), math.Sin(c.angleZ)
+
+	rotated := make([][3]float64, len(vertices))
+	for i, v := range vertices {
+		x, y, z := v[0], v[1], v[2]
+
+		// Rotate around X axis
+		y1 := y*cosX - z*sinX
+		z1 := y*sinX + z*cosX
+		y, z = y1, z1
+
+		// Rotate around Y axis
+		x1 := x*cosY + z*sinY
+		z2 := -x*sinY + z*cosY
+		x, z = x1, z2
+
+		// Rotate around Z axis
+		x2 := x*cosZ - y*sinZ
+		y2 := x*sinZ + y*cosZ
+		x, y = x2, y2
+
+		rotated[i] = [3]float64{x, y, z}
+	}
+	return rotated
+}
+
+// project maps rotated vertices to screen positions around centerX, centerY
+func (c *Cube3D) project(rotated [][3]float64, centerX, centerY float64) [][2]float64 {
+	projected := make([][2]float64, len(rotated))
+	for i, v := range rotated {
+		x2d, y2d := project3D(v[0], v[1], v[2], c.Perspective)
+		projected[i] = [2]float64{centerX + x2d, centerY + y2d}
+	}
+	return projected
+}
+
+// Cube faces, as indices into the rotatedVertices array
+var cubeFaces = [6][4]int{
+	{0, 1, 2, 3}, // Back
+	{4, 5, 6, 7}, // Front
+	{0, 1, 5, 4}, // Bottom
+	{2, 3, 7, 6}, // Top
+	{0, 3, 7, 4}, // Left
+	{1, 2, 6, 5}, // Right
+}
+
+// sortFaces orders the faces by the average z of their rotated corners,
+// back to front. project3D shrinks points as z grows, so the farthest face
+// has the largest z and is painted first. The result reuses c.depths.
+func (c *Cube3D) sortFaces(rotated [][3]float64) []faceDepth {
+	if len(c.depths) != len(cubeFaces) {
+		c.depths = make([]faceDepth, len(cubeFaces))
+	}
+	depths := c.depths
+
+	for i, face := range cubeFaces {
+		// Calculate center of face
+		centerZ := 0.0
+		for _, vi := range face {
+			centerZ += rotated[vi][2]
+		}
+		depths[i] = faceDepth{i, centerZ / 4}
+	}
+
+	sort.Slice(depths, func(i, j int) bool {
+		return depths[i].depth > depths[j].depth
+	})
+	return depths
+}
+
+// Draw draws the 3D cube at the specified position
+func (c *Cube3D) Draw(screen *ebiten.Image, centerX, centerY float64) {
+	faces := cubeFaces
+
+	palette := c.Palette
+	if len(palette) == 0 {
+		palette = defaultCubePalette
+	}
+
+	// Rotate the vertices, depth sorting needs their z, then project them
+	rotated := c.rotatedVertices()
+	projected := c.project(rotated, centerX, centerY)
+
+	// Draw faces
+	for _, fd := range c.sortFaces(rotated) {
+		face := faces[fd.index]
+		faceColor := palette[fd.index%len(palette)]
+		base := color.NRGBAModel.Convert(faceColor).(color.NRGBA)
+		fillColor := faceColor
+		if c.FaceAlpha < 1 {
+			fillColor = color.NRGBA{base.R, base.G, base.B, uint8(math.Max(c.FaceAlpha, 0) * float64(base.A))}
+		}
+
+		// Face outline in 2D
+		points := make([]float64, 0, 8)
+		for _, vi := range face {
+			points = append(points, projected[vi][0], projected[vi][1])
+		}
+
+		// Draw filled polygon
+		if !c.Wireframe {
+			if c.Antialias {
+				c.fillPath(screen, points, fillColor)
+			} else {
+				drawPolygon(screen, points, fillColor)
+			}
+		}
+
+		// Draw edges with darker color for better visibility
+		edgeColor := cubeEdgeColor(base)
+		for i := 0; i < 4; i++ {
+			j := (i + 1) % 4
+			vector.StrokeLine(screen,
+				float32(points[i*2]), float32(points[i*2+1]),
+				float32(points[j*2]), float32(points[j*2+1]),
+				1, edgeColor, false)
+		}
+	}
+}
+
+// whiteSubImage is the 1x1 source used to fill triangles with a flat color
+var whiteSubImage = func() *ebiten.Image {
+	img := ebiten.NewImage(3, 3)
+	img.Fill(color.White)
+	return img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+}()
+
+// fillPath fills the polygon as a closed vector path with antialiasing
+func (c *Cube3D) fillPath(screen *ebiten.Image, points []float64, fillColor color.Color) {
+	if len(points) < 6 {
+		return
+	}
+
+	c.path = vector.Path{}
+	c.path.MoveTo(float32(points[0]), float32(points[1]))
+	for i := 2; i < len(points); i += 2 {
+		c.path.LineTo(float32(points[i]), float32(points[i+1]))
+	}
+	c.path.Close()
+
+	c.vertices, c.indices = c.path.AppendVerticesAndIndicesForFilling(c.vertices[:0], c.indices[:0])
+
+	r, g, b, a := fillColor.RGBA()
+	for i := range c.vertices {
+		c.vertices[i].SrcX = 1
+		c.vertices[i].SrcY = 1
+		c.vertices[i].ColorR = float32(r) / 0xffff
+		c.vertices[i].ColorG = float32(g) / 0xffff
+		c.vertices[i].ColorB = float32(b) / 0xffff
+		c.vertices[i].ColorA = float32(a) / 0xffff
+	}
+
+	op := &ebiten.DrawTrianglesOptions{}
+	op.ColorScaleMode = ebiten.ColorScaleModePremultipliedAlpha
+	op.AntiAlias = true
+	screen.DrawTriangles(c.vertices, c.indices, whiteSubImage, op)
+}
+
+// drawPolygon draws a filled polygon. dst is usually the *ebiten.Image being
+// composed, but any draw.Image works, so the fill can also be rendered into
+// an *image.RGBA without a GPU.
+func drawPolygon(dst draw.Image, points []float64, fillColor color.Color) {
+	if len(points) < 6 {
+		return
+	}
+
+	// Draw as a filled rectangle using vector
+	if len(points) >= 8 {
+		// Draw filled quadrilateral as two triangles
+		// Triangle 1: points 0, 1, 2
+		drawTriangle(dst,
+			float32(points[0]), float32(points[1]),
+			float32(points[2]), float32(points[3]),
+			float32(points[4]), float32(points[5]),
+			fillColor)
+
+		// Triangle 2: points 0, 2, 3
+		drawTriangle(dst,
+			float32(points[0]), float32(points[1]),
+			float32(points[4]), float32(points[5]),
+			float32(points[6]), float32(points[7]),
+			fillColor)
+	}
+}
+
+// drawTriangle draws a filled triangle, one fillSpan per row.
+//
+// It samples pixel centers: every row whose center lies in [y1, y3) gets a
+// span covering the pixels whose centers lie in [left edge, right edge).
+// Two triangles sharing an edge therefore meet without seams and without
+// covering any pixel twice, which matters for translucent faces.
+func drawTriangle(dst draw.Image, x1, y1, x2, y2, x3, y3 float32, clr color.Color) {
+	triangleSpans(x1, y1, x2, y2, x3, y3, func(row, colStart, colEnd int) {
+		fillSpan(dst, row, colStart, colEnd, clr)
+	})
+}
+
+// fillSpan blends clr over the pixels [colStart, colEnd) of a row. An
+// *ebiten.Image gets a GPU rect; other images are drawn in software with the
+// same source-over blending, so both give the same pixels.
+func fillSpan(dst draw.Image, row, colStart, colEnd int, clr color.Color) {
+	if img, ok := dst.(*ebiten.Image); ok {
+		vector.DrawFilledRect(img, float32(colStart), float32(row), float32(colEnd-colStart), 1, clr, false)
+		return
+	}
+	draw.Draw(dst, image.Rect(colStart, row, colEnd, row+1), image.NewUniform(clr), image.Point{}, draw.Over)
+}
+
+// Edge positions are walked in 32.32 fixed point, fine enough that the
+// rounding of the per-row step never adds up to a visible drift. Only
+// positions within fixLimit pixels of the origin, far past any image, are
+// walked that way, so they never overflow it; see edgeWalk.
+const (
+	fixShift = 32
+	fixOne   = 1 << fixShift
+	fixHalf  = fixOne / 2
+	fixLimit = 1 << 24
+)
+
+// triangleSpans scan converts a triangle, calling span for each row with the
+// half-open column range [colStart, colEnd) to fill. The edges are walked
+// with a fixed-point x increment per row, so the only divisions are the
+// three slopes, and an edge shared by two triangles is always walked from
+// the same start with the same step: both sides agree on every row.
+func triangleSpans(x1, y1, x2, y2, x3, y3 float32, span func(row, colStart, colEnd int)) {
+	// Sort vertices by Y coordinate
+	if y1 > y2 {
+		x1, y1, x2, y2 = x2, y2, x1, y1
+	}
+	if y1 > y3 {
+		x1, y1, x3, y3 = x3, y3, x1, y1
+	}
+	if y2 > y3 {
+		x2, y2, x3, y3 = x3, y3, x2, y2
+	}
+
+	// Degenerate (zero height) triangle
+	if y3-y1 <= 0 {
+		return
+	}
+
+	// Rows whose pixel center is inside [y1, y2) and [y2, y3)
+	rowStart := int(math.Ceil(float64(y1) - 0.5))
+	rowMid := int(math.Ceil(float64(y2) - 0.5))
+	rowEnd := int(math.Ceil(float64(y3) - 0.5))
+
+	// Long edge 1-3 is always one side of the span
+	e13 := newEdgeWalk(x1, y1, x3, y3, rowStart)
+	walk := func(from, to int, e *edgeWalk) {
+		for row := from; row < to; row++ {
+			a, b := e13.next(), e.next()
+			if a > b {
+				a, b = b, a
+			}
+			// Pixels whose centers fall inside [a, b): ceil(v - 0.5)
+			colStart := int((a - fixHalf + fixOne - 1) >> fixShift)
+			colEnd := int((b - fixHalf + fixOne - 1) >> fixShift)
+			if colEnd > colStart {
+				span(row, colStart, colEnd)
+			}
+		}
+	}
+	if rowMid > rowStart {
+		e := newEdgeWalk(x1, y1, x2, y2, rowStart)
+		walk(rowStart, rowMid, &e)
+	}
+	if rowEnd > rowMid {
+		e := newEdgeWalk(x2, y2, x3, y3, rowMid)
+		walk(rowMid, rowEnd, &e)
+	}
+}
+
+// edgeWalk gives where an edge crosses the center of each row in turn, in
+// fixed point. An edge within fixLimit that moves at most fixLimit per row
+// is walked with a fixed-point step. One that reaches further, from a
+// vertex projected close to the camera or nearly horizontal, would overflow
+// it: its crossings are worked out row by row in floating point instead and
+// clamped to fixLimit, which still puts them off any image on the same side.
+type edgeWalk struct {
+	x, dx int64 // crossing of the next row and step, when walked
+
+	far           bool
+	row           int
+	xa, ya, slope float64
+}
+
+// newEdgeWalk starts walking the edge from (xa, ya) down to (xb, yb) at row
+func newEdgeWalk(xa, ya, xb, yb float32, row int) edgeWalk {
+	slope := float64(xb-xa) / float64(yb-ya)
+	e := edgeWalk{row: row, xa: float64(xa), ya: float64(ya), slope: slope}
+	e.far = math.Abs(e.xa) > fixLimit || math.Abs(float64(xb)) > fixLimit || math.Abs(slope) > fixLimit
+	if !e.far {
+		e.x = int64(math.Round(e.crossing() * fixOne))
+		e.dx = int64(math.Round(slope * fixOne))
+	}
+	return e
+}
+
+// crossing returns where the edge crosses the center of e.row, in pixels
+func (e *edgeWalk) crossing() float64 {
+	return e.xa + e.slope*(float64(e.row)+0.5-e.ya)
+}
+
+// next returns the crossing of the next row and moves on to the row below
+func (e *edgeWalk) next() int64 {
+	x := e.x
+	if e.far {
+		x = int64(math.Round(math.Max(-fixLimit, math.Min(e.crossing(), fixLimit)) * fixOne))
+	}
+	e.x += e.dx
+	e.row++
+	return x
+}
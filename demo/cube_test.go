@@ -0,0 +1,285 @@
+package demo
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSortFacesBackToFront(t *testing.T) {
+	tests := []struct {
+		name              string
+		ax, ay, az        float64
+		farthest, nearest int
+	}{
+		// At rest the +z face is the farthest from the camera
+		{"rest", 0, 0, 0, 1, 0},
+		{"half turn around x", math.Pi, 0, 0, 0, 1},
+		// z becomes -x: the left face moves to the back
+		{"quarter turn around y", 0, math.Pi / 2, 0, 4, 5},
+		// z becomes y: the top face moves to the back
+		{"quarter turn around x", math.Pi / 2, 0, 0, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCube3D(cubeSize)
+			c.Rotate(tt.ax, tt.ay, tt.az)
+			depths := c.sortFaces(c.rotatedVertices())
+			if got := depths[0].index; got != tt.farthest {
+				t.Errorf("first face drawn = %d, want %d", got, tt.farthest)
+			}
+			if got := depths[len(depths)-1].index; got != tt.nearest {
+				t.Errorf("last face drawn = %d, want %d", got, tt.nearest)
+			}
+		})
+	}
+}
+
+func TestSortFacesOrder(t *testing.T) {
+	c := NewCube3D(cubeSize)
+	c.SetSpin(0.37, 0.51, 0.23)
+	for step := 0; step < 100; step++ {
+		c.Spin(1)
+		depths := c.sortFaces(c.rotatedVertices())
+
+		seen := map[int]bool{}
+		for i, fd := range depths {
+			seen[fd.index] = true
+			if i > 0 && fd.depth > depths[i-1].depth {
+				t.Fatalf("step %d: face %d (z %.3f) drawn after nearer face %d (z %.3f)",
+					step, fd.index, fd.depth, depths[i-1].index, depths[i-1].depth)
+			}
+		}
+		if len(seen) != len(cubeFaces) {
+			t.Fatalf("step %d: faces %v, want each of the %d once", step, depths, len(cubeFaces))
+		}
+	}
+}
+
+func TestTriangleSpans(t *testing.T) {
+	type span struct{ row, colStart, colEnd int }
+	tests := []struct {
+		name   string
+		x1, y1 float32
+		x2, y2 float32
+		x3, y3 float32
+		want   []span
+	}{
+		{"flat top", 0, 0, 4, 0, 0, 4, []span{{0, 0, 3}, {1, 0, 2}, {2, 0, 1}}},
+		// The diagonal runs through the pixel centers, which [left, right)
+		// leaves out
+		{"flat bottom", 0, 0, 0, 4, 4, 4, []span{{1, 0, 1}, {2, 0, 2}, {3, 0, 3}}},
+		{"vertices in any order", 5, 3, 1, 1, 5, 1, []span{{1, 2, 5}, {2, 4, 5}}},
+		{"pixel centers only", 0.6, 0.6, 1.4, 0.6, 1, 1.4, nil},
+		{"zero height", 0, 2, 8, 2, 4, 2, nil},
+		// Barely tilted across a row center: the long edge moves 2^35
+		// pixels per row, far past 32.32 fixed point
+		{"nearly flat", 0, 0.49999997, 3000, 0.49999997, 3000, 0.50000006, []span{{0, 1000, 3000}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []span
+			triangleSpans(tt.x1, tt.y1, tt.x2, tt.y2, tt.x3, tt.y3, func(row, colStart, colEnd int) {
+				got = append(got, span{row, colStart, colEnd})
+			})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("spans = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTriangleSpansFarVertex fills a triangle reaching far off screen, like
+// a cube corner projected close to the camera: its edges are beyond 32.32
+// fixed point, so the spans must stop at fixLimit instead of wrapping
+func TestTriangleSpansFarVertex(t *testing.T) {
+	tests := []struct {
+		name     string
+		x2, y2   float32
+		from, to int
+	}{
+		{"right", 1e12, 50, 0, fixLimit},
+		{"left", -1e12, 50, -fixLimit, 0},
+	}
+	for _, tt := range tests {
+		rows := 0
+		triangleSpans(0, 0, tt.x2, tt.y2, 0, 100, func(row, colStart, colEnd int) {
+			if row != rows || colStart != tt.from || colEnd != tt.to {
+				t.Fatalf("%s: span %d = row %d [%d, %d), want row %d [%d, %d)", tt.name, rows, row, colStart, colEnd, rows, tt.from, tt.to)
+			}
+			rows++
+		})
+		if rows != 100 {
+			t.Errorf("%s: %d rows filled, want 100", tt.name, rows)
+		}
+	}
+}
+
+// A large tilted quad, drawn as two triangles, must cover exactly the
+// pixels whose centers are inside it: none missing along the shared
+// diagonal or the outer edges, none covered twice.
+func TestDrawPolygonNoSeams(t *testing.T) {
+	quad := []float64{50.3, 10.7, 190.2, 60.1, 140.6, 200.4, 10.1, 150.9}
+	fill := color.NRGBA{0xff, 0x80, 0x00, 0x80}
+	dst := image.NewRGBA(image.Rect(0, 0, 200, 210))
+	drawPolygon(dst, quad, fill)
+
+	want := color.RGBAModel.Convert(fill).(color.RGBA)
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			inside, onEdge := insideConvex(quad, float64(x)+0.5, float64(y)+0.5)
+			if onEdge {
+				continue
+			}
+			got := dst.RGBAAt(x, y)
+			switch {
+			case inside && got != want:
+				t.Fatalf("pixel (%d, %d) inside the quad = %v, want %v", x, y, got, want)
+			case !inside && got != (color.RGBA{}):
+				t.Fatalf("pixel (%d, %d) outside the quad = %v, want transparent", x, y, got)
+			}
+		}
+	}
+}
+
+// insideConvex reports whether (px, py) is inside the clockwise convex
+// polygon points, and whether it is too close to an edge to tell
+func insideConvex(points []float64, px, py float64) (inside, onEdge bool) {
+	n := len(points) / 2
+	inside = true
+	for i := range n {
+		ax, ay := points[2*i], points[2*i+1]
+		bx, by := points[2*((i+1)%n)], points[2*((i+1)%n)+1]
+		cross := (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+		if math.Abs(cross)/math.Hypot(bx-ax, by-ay) < 1e-6 {
+			onEdge = true
+		}
+		if cross < 0 {
+			inside = false
+		}
+	}
+	return inside, onEdge
+}
+
+// TestTriangleSpansReference checks triangleSpans against a brute force
+// test of every pixel center: each one strictly inside is covered once,
+// each one outside is not. Centers exactly on an edge may go either way.
+func TestTriangleSpansReference(t *testing.T) {
+	tests := []struct {
+		name string
+		tri  [6]float32
+	}{
+		{"large", [6]float32{12.3, 4.8, 180.6, 60.2, 70.1, 190.7}},
+		{"counterclockwise", [6]float32{12.3, 4.8, 70.1, 190.7, 180.6, 60.2}},
+		{"thin sliver", [6]float32{0.2, 0.1, 200.9, 3.4, 0.7, 1.9}},
+		{"tall sliver", [6]float32{5.5, 0.3, 7.9, 240.2, 6.1, 120.8}},
+		{"small", [6]float32{3.2, 3.9, 5.8, 4.1, 4.4, 6.6}},
+		{"negative coordinates", [6]float32{-30.4, -12.2, 40.6, -3.3, 5.1, 50.5}},
+		{"integer corners", [6]float32{0, 0, 64, 16, 16, 64}},
+		// A long shallow edge, where a float step would drift
+		{"long edge", [6]float32{-0.3, 0.4, 4000.7, 37.9, 12.2, 39.6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.tri
+			covered := map[[2]int]int{}
+			triangleSpans(v[0], v[1], v[2], v[3], v[4], v[5], func(row, colStart, colEnd int) {
+				for col := colStart; col < colEnd; col++ {
+					covered[[2]int{col, row}]++
+				}
+			})
+
+			// The triangle clockwise on screen, as insideConvex wants it
+			points := []float64{float64(v[0]), float64(v[1]), float64(v[2]), float64(v[3]), float64(v[4]), float64(v[5])}
+			if (points[2]-points[0])*(points[5]-points[1])-(points[3]-points[1])*(points[4]-points[0]) < 0 {
+				points[2], points[3], points[4], points[5] = points[4], points[5], points[2], points[3]
+			}
+			minX, maxX := math.Min(math.Min(points[0], points[2]), points[4]), math.Max(math.Max(points[0], points[2]), points[4])
+			minY, maxY := math.Min(math.Min(points[1], points[3]), points[5]), math.Max(math.Max(points[1], points[3]), points[5])
+
+			inside := 0
+			for y := int(math.Floor(minY)) - 1; y <= int(math.Ceil(maxY)); y++ {
+				for x := int(math.Floor(minX)) - 1; x <= int(math.Ceil(maxX)); x++ {
+					in, onEdge := insideConvex(points, float64(x)+0.5, float64(y)+0.5)
+					n := covered[[2]int{x, y}]
+					delete(covered, [2]int{x, y})
+					switch {
+					case n > 1:
+						t.Fatalf("pixel (%d, %d) covered %d times", x, y, n)
+					case onEdge:
+					case in && n == 0:
+						t.Fatalf("pixel (%d, %d) inside the triangle not covered", x, y)
+					case !in && n > 0:
+						t.Fatalf("pixel (%d, %d) outside the triangle covered", x, y)
+					}
+					if in {
+						inside++
+					}
+				}
+			}
+			if len(covered) > 0 {
+				t.Errorf("%d pixels covered outside the bounding box", len(covered))
+			}
+			if inside == 0 {
+				t.Error("no pixel center inside the triangle, the test checks nothing")
+			}
+		})
+	}
+}
+
+func TestProjectedVertices(t *testing.T) {
+	const near, far = 200.0 / 150, 200.0 / 250 // perspective at z = -50, +50
+	tests := []struct {
+		name       string
+		ax, ay, az float64
+		want       [8][2]float64 // offsets from the center
+	}{
+		{"unturned", 0, 0, 0, [8][2]float64{
+			{-50 * near, -50 * near}, {50 * near, -50 * near}, {50 * near, 50 * near}, {-50 * near, 50 * near},
+			{-50 * far, -50 * far}, {50 * far, -50 * far}, {50 * far, 50 * far}, {-50 * far, 50 * far},
+		}},
+		// A quarter turn around Y swings the corners at x = -50 round to z = +50
+		{"quarter turn around Y", 0, math.Pi / 2, 0, [8][2]float64{
+			{-50 * far, -50 * far}, {-50 * near, -50 * near}, {-50 * near, 50 * near}, {-50 * far, 50 * far},
+			{50 * far, -50 * far}, {50 * near, -50 * near}, {50 * near, 50 * near}, {50 * far, 50 * far},
+		}},
+		// Around Z the cube turns in the screen plane, depths unchanged
+		{"quarter turn around Z", 0, 0, math.Pi / 2, [8][2]float64{
+			{50 * near, -50 * near}, {50 * near, 50 * near}, {-50 * near, 50 * near}, {-50 * near, -50 * near},
+			{50 * far, -50 * far}, {50 * far, 50 * far}, {-50 * far, 50 * far}, {-50 * far, -50 * far},
+		}},
+		// Half a turn around X swaps the front and back, upside down
+		{"half turn around X", math.Pi, 0, 0, [8][2]float64{
+			{-50 * far, 50 * far}, {50 * far, 50 * far}, {50 * far, -50 * far}, {-50 * far, -50 * far},
+			{-50 * near, 50 * near}, {50 * near, 50 * near}, {50 * near, -50 * near}, {-50 * near, -50 * near},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCube3D(100)
+			c.Rotate(tt.ax, tt.ay, tt.az)
+			got := c.ProjectedVertices(400, 300)
+			if len(got) != 8 {
+				t.Fatalf("%d vertices, want 8", len(got))
+			}
+			for i, w := range tt.want {
+				if math.Abs(got[i][0]-400-w[0]) > 1e-9 || math.Abs(got[i][1]-300-w[1]) > 1e-9 {
+					t.Errorf("vertex %d at %.3f, want %.3f", i, got[i], [2]float64{400 + w[0], 300 + w[1]})
+				}
+			}
+		})
+	}
+}
+
+func TestProjectNearCamera(t *testing.T) {
+	// With the camera 10 away, the back corners at z = -50 would be behind
+	// it; they are held at minProjectDepth instead of flipping over
+	c := NewCube3D(100)
+	c.Perspective = 10
+	got := c.ProjectedVertices(0, 0)
+	if want := [2]float64{-50 * 10, -50 * 10}; got[0] != want {
+		t.Errorf("vertex 0 at %v, want %v", got[0], want)
+	}
+}
@@ -0,0 +1,92 @@
+package demo
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Face colors of the cubes unless a palette is set, in orange tones
+var defaultCubePalette = []color.Color{
+	color.RGBA{255, 140, 0, 255},   // Dark orange
+	color.RGBA{255, 165, 50, 255},  // Orange
+	color.RGBA{255, 180, 80, 255},  // Light orange
+	color.RGBA{255, 120, 0, 255},   // Deep orange
+	color.RGBA{255, 150, 30, 255},  // Medium orange
+	color.RGBA{255, 200, 100, 255}, // Pale orange
+}
+
+// Named cube palettes, for ParseCubePalette
+var cubePalettes = map[string][]color.Color{
+	"orange": defaultCubePalette,
+	"cyan": {
+		color.RGBA{0, 200, 255, 255},
+		color.RGBA{60, 220, 255, 255},
+		color.RGBA{110, 235, 255, 255},
+		color.RGBA{0, 170, 230, 255},
+		color.RGBA{30, 190, 240, 255},
+		color.RGBA{160, 245, 255, 255},
+	},
+	"rainbow": {
+		color.RGBA{255, 40, 40, 255},
+		color.RGBA{255, 150, 0, 255},
+		color.RGBA{255, 235, 0, 255},
+		color.RGBA{40, 220, 60, 255},
+		color.RGBA{40, 120, 255, 255},
+		color.RGBA{170, 60, 255, 255},
+	},
+	"mono": {
+		color.RGBA{220, 220, 220, 255},
+	},
+}
+
+// ParseCubePalette reads a cube palette: orange (the default), cyan,
+// rainbow, mono, or a comma separated list of #rrggbb face colors
+func ParseCubePalette(s string) ([]color.Color, error) {
+	if p, ok := cubePalettes[s]; ok {
+		return p, nil
+	}
+	var palette []color.Color
+	for _, name := range strings.Split(s, ",") {
+		c, ok := parseHexColor(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown cube palette %q, want orange, cyan, rainbow, mono or #rrggbb,...", s)
+		}
+		palette = append(palette, c)
+	}
+	return palette, nil
+}
+
+// SetCubePalette colors the faces of every cube without a palette of its
+// own (see SetCubeColors) from palette, nil for the default orange
+func (g *Game) SetCubePalette(palette []color.Color) {
+	g.cubePalette = palette
+}
+
+// SetCubeColors gives cube i a palette of its own instead of the one set
+// with SetCubePalette; nil goes back to that one
+func (g *Game) SetCubeColors(i int, palette []color.Color) {
+	if i < 0 || i >= nbCubes {
+		return
+	}
+	g.cubeColorsOf[i] = palette
+}
+
+// cubeColors returns the palette cube i is drawn with
+func (g *Game) cubeColors(i int) []color.Color {
+	if g.cubeColorsOf[i] != nil {
+		return g.cubeColorsOf[i]
+	}
+	return g.cubePalette
+}
+
+// cubeEdgeColor darkens a face color to three quarters for the cube edges,
+// which stay opaque whatever the face alpha
+func cubeEdgeColor(face color.NRGBA) color.RGBA {
+	return color.RGBA{
+		uint8(int(face.R) * 3 / 4),
+		uint8(int(face.G) * 3 / 4),
+		uint8(int(face.B) * 3 / 4),
+		255,
+	}
+}
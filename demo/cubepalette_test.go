@@ -0,0 +1,97 @@
+package demo
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestParseCubePalette(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []color.Color
+		wantErr bool
+	}{
+		{s: "orange", want: defaultCubePalette},
+		{s: "cyan", want: cubePalettes["cyan"]},
+		{s: "rainbow", want: cubePalettes["rainbow"]},
+		{s: "mono", want: cubePalettes["mono"]},
+		{s: "#ff0000", want: []color.Color{color.RGBA{0xff, 0, 0, 0xff}}},
+		{s: "#ff0000,#00FF00, #0000ff", want: []color.Color{
+			color.RGBA{0xff, 0, 0, 0xff},
+			color.RGBA{0, 0xff, 0, 0xff},
+			color.RGBA{0, 0, 0xff, 0xff},
+		}},
+		{s: "", wantErr: true},
+		{s: "pink", wantErr: true},
+		{s: "Orange", wantErr: true},
+		{s: "ff0000", wantErr: true},
+		{s: "#f00", wantErr: true},
+		{s: "#ff00000", wantErr: true},
+		{s: "#gg0000", wantErr: true},
+		{s: "#ff0000,", wantErr: true},
+		{s: "#ff0000,cyan", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCubePalette(tt.s)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseCubePalette(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCubeEdgeColor(t *testing.T) {
+	tests := []struct {
+		face color.NRGBA
+		want color.RGBA
+	}{
+		{color.NRGBA{0, 0, 0, 255}, color.RGBA{0, 0, 0, 255}},
+		// 255*3 overflows a uint8: it must not wrap to 253/4 = 63
+		{color.NRGBA{255, 255, 255, 255}, color.RGBA{191, 191, 191, 255}},
+		{color.NRGBA{255, 140, 0, 255}, color.RGBA{191, 105, 0, 255}},
+		{color.NRGBA{100, 86, 4, 255}, color.RGBA{75, 64, 3, 255}},
+		// Edges are opaque whatever the face alpha
+		{color.NRGBA{200, 200, 200, 0}, color.RGBA{150, 150, 150, 255}},
+	}
+	for _, tt := range tests {
+		if got := cubeEdgeColor(tt.face); got != tt.want {
+			t.Errorf("cubeEdgeColor(%v) = %v, want %v", tt.face, got, tt.want)
+		}
+	}
+}
+
+// TestCubePaletteDrawn draws a cube in a single color and checks it is the
+// only one on screen, darkened on the edges
+func TestCubePaletteDrawn(t *testing.T) {
+	needGPU(t)
+	const w, h = 320, 240
+	face := color.RGBA{0x20, 0x40, 0xff, 0xff}
+	edge := cubeEdgeColor(color.NRGBA(face))
+
+	c := NewCube3D(cubeSize)
+	c.SetSpin(defaultCubeSpin(0))
+	c.Spin(10)
+	c.Palette = []color.Color{face}
+	dst := ebiten.NewImage(w, h)
+	c.Draw(dst, w/2, h/2)
+
+	pix := make([]byte, 4*w*h)
+	dst.ReadPixels(pix)
+	faces, edges := 0, 0
+	for i := 0; i < len(pix); i += 4 {
+		switch got := (color.RGBA{pix[i], pix[i+1], pix[i+2], pix[i+3]}); got {
+		case face:
+			faces++
+		case edge:
+			edges++
+		case color.RGBA{}:
+		default:
+			t.Fatalf("pixel %d, %d is %v, not the face %v or edge %v", i/4%w, i/4/w, got, face, edge)
+		}
+	}
+	if faces == 0 || edges == 0 {
+		t.Errorf("%d face and %d edge pixels drawn, want both", faces, edges)
+	}
+}
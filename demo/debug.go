@@ -0,0 +1,58 @@
+package demo
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Debug overlay layout
+const (
+	debugGridStep   = 100
+	debugLabelScale = 0.4
+	debugCrossSize  = 8
+)
+
+var (
+	debugGridColor = color.RGBA{0x00, 0xff, 0xff, 0x60}
+	debugCubeColor = color.RGBA{0xff, 0xff, 0x00, 0xff}
+	debugLogoColor = color.RGBA{0xff, 0x00, 0xff, 0xff}
+)
+
+// drawDebug draws a 100px grid with coordinate labels and a crosshair on
+// every cube (yellow) and DMA logo (magenta) at the position it is drawn at,
+// and the audio gap counts in the bottom left corner
+func (g *Game) drawDebug(dst *ebiten.Image) {
+	for x := debugGridStep; x < g.width; x += debugGridStep {
+		vector.StrokeLine(dst, float32(x), 0, float32(x), float32(g.height), 1, debugGridColor, false)
+		g.font.DrawText(dst, strconv.Itoa(x), float64(x)+2, 2, debugLabelScale)
+	}
+	for y := debugGridStep; y < g.height; y += debugGridStep {
+		vector.StrokeLine(dst, 0, float32(y), float32(g.width), float32(y), 1, debugGridColor, false)
+		g.font.DrawText(dst, strconv.Itoa(y), 2, float64(y)+2, debugLabelScale)
+	}
+
+	for i := range g.cubes {
+		x, y := g.cubePosition(i)
+		drawCrosshair(dst, x, y, debugCubeColor)
+	}
+	for _, sprite := range g.dmaSprites {
+		drawCrosshair(dst, sprite.x, sprite.y, debugLogoColor)
+	}
+
+	if r, ok := g.music.(statsReporter); ok {
+		s := r.Stats()
+		text := fmt.Sprintf("UNDERRUNS %d SILENT %d EOF %d", s.Underruns, s.SilentFrames, s.EOFs)
+		g.font.DrawText(dst, text, 2, float64(g.height)-fontHeight*debugLabelScale-2, debugLabelScale)
+	}
+}
+
+// drawCrosshair marks x, y with a small cross
+func drawCrosshair(dst *ebiten.Image, x, y float64, clr color.Color) {
+	fx, fy := float32(x), float32(y)
+	vector.StrokeLine(dst, fx-debugCrossSize, fy, fx+debugCrossSize, fy, 1, clr, false)
+	vector.StrokeLine(dst, fx, fy-debugCrossSize, fx, fy+debugCrossSize, 1, clr, false)
+}
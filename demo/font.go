@@ -0,0 +1,127 @@
+package demo
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Height of a glyph in the font sheet
+const fontHeight = 36
+
+// Letter for font rendering
+type Letter struct {
+	x, y  int
+	width int
+	glyph *ebiten.Image // font sheet region, cut once in NewFont
+}
+
+// Font is the DMA bitmap font: a sheet of 36px high glyphs of varying width
+type Font struct {
+	img     *ebiten.Image
+	letters map[rune]*Letter
+}
+
+// fontMap places each glyph on the font sheet: its top left corner and width
+var fontMap = []struct {
+	char  rune
+	x, y  int
+	width int
+}{
+	{' ', 0, 0, 32}, {'!', 48, 0, 16}, {'"', 96, 0, 32},
+	{'\'', 336, 0, 16}, {'(', 384, 0, 32}, {')', 432, 0, 32},
+	{'+', 48, 36, 48}, {',', 96, 36, 16}, {'-', 144, 36, 32},
+	{'.', 192, 36, 16}, {'0', 288, 36, 48}, {'1', 336, 36, 48},
+	{'2', 384, 36, 48}, {'3', 432, 36, 48}, {'4', 0, 72, 48},
+	{'5', 48, 72, 48}, {'6', 96, 72, 48}, {'7', 144, 72, 48},
+	{'8', 192, 72, 48}, {'9', 240, 72, 48}, {':', 288, 72, 16},
+	{';', 336, 72, 16}, {'<', 384, 72, 32}, {'=', 432, 72, 32},
+	{'>', 0, 108, 32}, {'?', 48, 108, 48}, {'A', 144, 108, 48},
+	{'B', 192, 108, 48}, {'C', 240, 108, 48}, {'D', 288, 108, 48},
+	{'E', 336, 108, 48}, {'F', 384, 108, 48}, {'G', 432, 108, 48},
+	{'H', 0, 144, 48}, {'I', 48, 144, 16}, {'J', 96, 144, 48},
+	{'K', 144, 144, 48}, {'L', 192, 144, 48}, {'M', 240, 144, 48},
+	{'N', 288, 144, 48}, {'O', 336, 144, 48}, {'P', 384, 144, 48},
+	{'Q', 432, 144, 48}, {'R', 0, 180, 48}, {'S', 48, 180, 48},
+	{'T', 96, 180, 48}, {'U', 144, 180, 48}, {'V', 192, 180, 48},
+	{'W', 240, 180, 48}, {'X', 288, 180, 48}, {'Y', 336, 180, 48},
+	{'Z', 384, 180, 48},
+}
+
+// NewFont maps the glyphs of the DMA font sheet
+func NewFont(img *ebiten.Image) *Font {
+	f := &Font{
+		img:     img,
+		letters: make(map[rune]*Letter),
+	}
+
+	for _, d := range fontMap {
+		letter := &Letter{x: d.x, y: d.y, width: d.width}
+		letter.glyph = img.SubImage(image.Rect(d.x, d.y, d.x+d.width, d.y+fontHeight)).(*ebiten.Image)
+		f.letters[d.char] = letter
+	}
+
+	return f
+}
+
+// Letter returns the glyph for r, if the font has one
+func (f *Font) Letter(r rune) (*Letter, bool) {
+	letter, ok := f.letters[r]
+	return letter, ok
+}
+
+// narrowest returns the width of the narrowest glyph, 0 for an empty font
+func (f *Font) narrowest() int {
+	n := 0
+	for _, letter := range f.letters {
+		if n == 0 || letter.width < n {
+			n = letter.width
+		}
+	}
+	return n
+}
+
+// Glyph returns the font sheet region of a letter. The sub-images are cut
+// once when the font is built, so the per-frame glyph loops of the intro and
+// the scroller just reuse them.
+func (f *Font) Glyph(letter *Letter) *ebiten.Image {
+	return letter.glyph
+}
+
+// DrawText draws text with its top left corner at x, y, scaled by scale.
+// Runes missing from the font are skipped.
+func (f *Font) DrawText(dst *ebiten.Image, text string, x, y, scale float64) {
+	f.drawSpaced(dst, text, x, y, scale, 0)
+}
+
+// drawSpaced is DrawText with spacing font pixels between glyphs
+func (f *Font) drawSpaced(dst *ebiten.Image, text string, x, y, scale float64, spacing int) {
+	op := &ebiten.DrawImageOptions{}
+	for _, r := range text {
+		letter, ok := f.Letter(r)
+		if !ok {
+			continue
+		}
+		op.GeoM.Reset()
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(x, y)
+		dst.DrawImage(f.Glyph(letter), op)
+		x += float64(letter.width+spacing) * scale
+	}
+}
+
+// measureSpaced returns the width in font pixels of text drawn with spacing
+// font pixels between glyphs, not counting any after the last one
+func (f *Font) measureSpaced(text string, spacing int) int {
+	width, glyphs := 0, 0
+	for _, r := range text {
+		if letter, ok := f.Letter(r); ok {
+			width += letter.width
+			glyphs++
+		}
+	}
+	if glyphs > 1 {
+		width += (glyphs - 1) * spacing
+	}
+	return width
+}
@@ -0,0 +1,75 @@
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// Layout of the font dump: fontDumpCols cells per row, each holding a glyph
+// above its label
+const (
+	fontDumpCols   = 8
+	fontDumpCellW  = 104
+	fontDumpCellH  = fontHeight + fontHeight/2 + 16
+	fontDumpMargin = 8
+)
+
+// DumpFont writes a PNG sheet of every glyph in the font map, cut from the
+// font sheet with the same regions NewFont uses. Each glyph sits in a box
+// of its mapped width, over a label giving the rune and its hex code in the
+// font at half size, so a wrong x, y or width stands out. It draws in
+// software, so it runs without a window or a GPU.
+func DumpFont(w io.Writer, fontPNG []byte) error {
+	sheet, err := png.Decode(bytes.NewReader(fontPNG))
+	if err != nil {
+		return fmt.Errorf("decoding font sheet: %w", err)
+	}
+
+	rows := (len(fontMap) + fontDumpCols - 1) / fontDumpCols
+	out := image.NewRGBA(image.Rect(0, 0, fontDumpCols*fontDumpCellW, rows*fontDumpCellH))
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.RGBA{0x20, 0x20, 0x30, 0xff}), image.Point{}, draw.Src)
+
+	box := color.RGBA{0xff, 0x40, 0x40, 0xff}
+	for i, d := range fontMap {
+		x := (i%fontDumpCols)*fontDumpCellW + fontDumpMargin
+		y := (i/fontDumpCols)*fontDumpCellH + fontDumpMargin
+
+		// The glyph region, and a frame just outside it
+		frame := image.Rect(x-1, y-1, x+d.width+1, y+fontHeight+1)
+		draw.Draw(out, frame, image.NewUniform(box), image.Point{}, draw.Src)
+		draw.Draw(out, frame.Inset(1), image.Black, image.Point{}, draw.Src)
+		draw.Draw(out, frame.Inset(1), sheet, image.Pt(d.x, d.y), draw.Over)
+
+		label := fmt.Sprintf("%c %X", d.char, d.char)
+		drawSheetText(out, sheet, label, x, y+fontHeight+4, 2)
+	}
+
+	return png.Encode(w, out)
+}
+
+// drawSheetText draws text from the font sheet into dst, shrunk by an
+// integer factor with nearest sampling: the software twin of Font.DrawText
+func drawSheetText(dst draw.Image, sheet image.Image, text string, x, y, shrink int) {
+	for _, r := range text {
+		for _, d := range fontMap {
+			if d.char != r {
+				continue
+			}
+			for py := 0; py < fontHeight/shrink; py++ {
+				for px := 0; px < d.width/shrink; px++ {
+					c := sheet.At(d.x+px*shrink, d.y+py*shrink)
+					if _, _, _, a := c.RGBA(); a > 0 {
+						dst.Set(x+px, y+py, c)
+					}
+				}
+			}
+			x += d.width / shrink
+			break
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package demo
+
+import "math"
+
+// Formation is how the cubes are laid out on screen
+type Formation int
+
+const (
+	// FormationWave sends each cube along its own sine path, the default
+	FormationWave Formation = iota
+	// FormationLine lines the cubes up across the middle of the screen
+	FormationLine
+	// FormationCircle turns the cubes around the middle of the screen
+	FormationCircle
+	// FormationGrid lays the cubes out in rows beside the banner
+	FormationGrid
+
+	formationCount
+)
+
+const (
+	// Music level, out of a quarter of full scale, a beat has to rise
+	// through to change the formation
+	beatThreshold = 0.6
+
+	// Shortest time between two formation changes, so only the strong
+	// beats of a bar move the cubes rather than every drum hit
+	beatCooldownTicks = baseTPS / 2
+
+	// How long the cubes take to glide into a new formation
+	formationTicks = 20
+)
+
+// SetBeatFormations makes the cubes jump to the next formation on strong
+// beats of the music. Off, they glide back to the wave.
+func (g *Game) SetBeatFormations(on bool) {
+	g.beatFormations = on
+	if !on {
+		g.setFormation(FormationWave)
+	}
+}
+
+// setFormation starts the glide from where the cubes are now to formation f
+func (g *Game) setFormation(f Formation) {
+	if f == g.formation {
+		return
+	}
+	for i := range g.formationFrom {
+		g.formationFrom[i][0], g.formationFrom[i][1] = g.cubePosition(i)
+	}
+	g.formation = f
+	g.formationT = 0
+}
+
+// updateFormation moves the glide on and watches the music for a beat: the
+// level rising through beatThreshold, at least beatCooldownTicks after the
+// last change
+func (g *Game) updateFormation() {
+	g.formationT = math.Min(g.formationT+g.step/formationTicks, 1)
+	g.beatCooldown = math.Max(g.beatCooldown-g.step, 0)
+
+	level := 0.0
+	if g.beatFormations && g.music != nil && g.audioPlayer != nil && g.audioPlayer.IsPlaying() {
+		_, rms := g.music.GetLevels()
+		level = rms / 0.25
+	}
+	if level >= beatThreshold && g.beatLevel < beatThreshold && g.beatCooldown == 0 {
+		g.setFormation((g.formation + 1) % formationCount)
+		g.beatCooldown = beatCooldownTicks
+	}
+	g.beatLevel = level
+}
+
+// cubePosition returns the screen position of cube i, partway from where it
+// was to its place in the formation during a glide
+func (g *Game) cubePosition(i int) (x, y float64) {
+	x, y = g.formationPosition(g.formation, i)
+	if g.formationT >= 1 {
+		return x, y
+	}
+	// Ease in and out
+	t := g.formationT * g.formationT * (3 - 2*g.formationT)
+	from := g.formationFrom[i]
+	return from[0] + (x-from[0])*t, from[1] + (y-from[1])*t
+}
+
+// formationPosition returns where cube i sits in formation f
+func (g *Game) formationPosition(f Formation, i int) (x, y float64) {
+	w, h := float64(g.width), float64(g.height)
+	top, fh := float64(g.playfieldTop()), float64(g.height-g.bannerHeight)
+	centerY := top + fh/2
+
+	switch f {
+	case FormationLine:
+		return (float64(i) + 0.5) * w / nbCubes, centerY
+	case FormationCircle:
+		radius := math.Min(w, fh) * 0.35
+		a := 2*math.Pi*float64(i)/nbCubes + g.ctrSprite
+		return w/2 + radius*math.Cos(a), centerY + radius*math.Sin(a)
+	case FormationGrid:
+		const cols, rows = 4, (nbCubes + 3) / 4
+		col, row := i%cols, i/cols
+		return (float64(col) + 0.5) * w / cols, top + (float64(row)+0.5)*fh/rows
+	}
+
+	x = float64((g.width-40)/2) + (float64((g.width-40)/2) * math.Sin(g.spritePos[i]))
+	y = h/2 + (h * 0.14 * math.Cos(g.spritePos[i]*2.5)) // Centered vertically
+	return x, y
+}
@@ -0,0 +1,1554 @@
+// Package demo implements the COCO IS THE BEST demo: the full ebiten.Game
+// and the effects it is made of (copper bars, megatwist scroller, rotozoom,
+// 3D cubes) plus the YM music player, each usable on its own.
+package demo
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// Logical resolution of the demo
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	// Audio output rate, 16-bit stereo
+	SampleRate = 44100
+
+	screenWidth  = ScreenWidth
+	screenHeight = ScreenHeight
+
+	// Constants for effects
+	nbCubes  = 12
+	cubeSize = 40 // edge of a cube at rest
+
+	// Default DMA logo grid, and its cell size at the default resolution.
+	// The 140px rows overlap the 528px playfield a little on purpose.
+	defaultDMARows = 4
+	defaultDMACols = 4
+	dmaCellWidth   = 200
+	dmaCellHeight  = 140
+
+	defaultBannerHeight = 72
+
+	// Fraction of the title logo width that always stays on screen
+	logoMinVisible = 0.25
+
+	sampleRate = SampleRate
+
+	// The animation constants are per tick at this rate
+	baseTPS = 60.0
+)
+
+// Assets holds the encoded files the demo is built from
+type Assets struct {
+	Title   []byte // PNG, banner logo
+	Bars    []byte // PNG, copper bar stripes
+	Coco    []byte // PNG, rotozoom tile
+	DMALogo []byte // PNG, sprite logo
+	Font    []byte // PNG, DMA bitmap font sheet
+	Music   []byte // YM tune
+	Icon    []byte // PNG, window icon (optional)
+
+	// MusicPath optionally names a YM file to play instead of Music. If it
+	// can't be read or loaded the demo falls back to Music.
+	MusicPath string
+
+	// SecondMusic, or the file SecondMusicPath names, is an optional second
+	// tune. When set, the SwitchTrack key crossfades between the two.
+	SecondMusic     []byte
+	SecondMusicPath string
+}
+
+// Game state
+type Game struct {
+	// Images
+	icon       image.Image
+	titleImg   *ebiten.Image
+	dmaLogoImg *ebiten.Image
+
+	// Logical size, see SetBounds
+	width  int
+	height int
+
+	// Canvases
+	introCanvas *ebiten.Image
+	mainCanvas  *ebiten.Image
+	titleCanvas *ebiten.Image
+
+	// Audio
+	audioContext *audio.Context
+	audioPlayer  *audio.Player
+	music        ChipPlayer
+
+	// State
+	state         string // "intro" or "demo"
+	introComplete bool
+	iteration     float64 // demo ticks at 60 TPS, counting fractions
+
+	// Intro scrolling: how far the line has moved in from the right edge,
+	// and the next letter to draw with where it starts on the line. The
+	// letters are drawn once into introRing, a ring buffer the visible
+	// window slides along, and composed into introStrip each frame.
+	introScroll float64
+	introNext   int
+	introPen    int
+	introSpeed  int
+	introText   string
+	introRunes  []rune // introText as the font can draw it
+	introRing   *ebiten.Image
+	introStrip  *ebiten.Image
+
+	// Megatwist scroller direction, +1 forward or -1 backward
+	scrollDir int
+
+	// Extra space between overlay glyphs, see SetLetterSpacing
+	letterSpacing int
+
+	// Bitmap font, and whether its scaled glyphs are smoothed
+	font       *Font
+	fontSmooth bool
+
+	// Drop shadow under the scroll text, see SetScrollShadow
+	scrollShadow                 bool
+	scrollShadowX, scrollShadowY int
+
+	// CRT Shader, and ticks left of the notice shown when it failed
+	crtShader    *ebiten.Shader
+	shaderNotice int
+	vignette     float64 // corner darkening, see SetVignette
+
+	// Demo effects
+	copper   *CopperBars
+	scroller *Scroller
+	roto     *Rotozoom
+
+	// Rotozoom held still while the rest moves, see SetRotozoomFrozen, and
+	// its tile filtered, see SetRotozoomSmooth
+	rotozoomFrozen bool
+	rotozoomSmooth bool
+
+	// The effects in draw order, see SetLayers
+	layers []layer
+
+	// Source of randomness for the effects, see SetSeed
+	rng *rand.Rand
+
+	// Optional starfield behind the rotozoom, see SetStarfield
+	starfield *Starfield
+	stars     bool
+
+	// 3D Cubes, and the one held with the mouse (-1 if none) with the last
+	// cursor position, see updateGrab
+	cubes        []*Cube3D
+	spritePos    []float64
+	grabbed      int
+	grabX, grabY float64
+
+	// Cube layout, and the glide into it from formationFrom (formationT
+	// from 0 to 1), changed on the beat when beatFormations is on, see
+	// updateFormation
+	formation      Formation
+	formationFrom  [nbCubes][2]float64
+	formationT     float64
+	beatFormations bool
+	beatLevel      float64
+	beatCooldown   float64
+
+	// Cube breathing, see SetCubePulse
+	cubePulse      float64 // relative swing, 0 = fixed size
+	cubePulseSpeed float64 // radians per 60 TPS tick
+	cubePulsePhase float64
+
+	// Cube face colors, see SetCubePalette and SetCubeColors
+	cubePalette  []color.Color
+	cubeColorsOf [nbCubes][]color.Color
+
+	// DMA logo sprites, dmaRows x dmaCols grid
+	dmaRows        int
+	dmaCols        int
+	dmaSprites     []DMASprite
+	ctrSprite      float64
+	dmaIndependent bool // per-sprite phase instead of a rigid grid
+
+	// DMA logo look: base scale and alpha, and how much they breathe
+	dmaScale      float64
+	dmaAlpha      float64
+	dmaPulse      float64 // relative swing, 0 = static
+	dmaPulseSpeed float64 // radians per frame
+	dmaPulseAudio bool    // follow the music level instead of a sine
+
+	// Title banner (copper bars + logo), at the top of the screen unless
+	// bannerBottom, see SetBannerBottom
+	bannerHeight int
+	bannerBottom bool
+
+	// Uniform scale of the title logo, see updateTitleScale
+	titleScale float64
+
+	// Title logo swing: logoCenter is the X of the logo's middle at rest,
+	// logoAmplitude how far it travels either side
+	logoCenter    float64
+	logoAmplitude float64
+
+	// Title logo animation
+	logoX    float64
+	hold     int
+	rasterY1 float64
+	rasterY2 float64
+
+	// Speed control: the multiplier the keys set, and the one the motion
+	// uses, easing toward it, see easeSpeed
+	speedMultiplier float64
+	speed           float64
+
+	// Length of the current tick in 60 TPS ticks, see tickStep
+	step float64
+
+	// Music level envelope driving the copper bars
+	audioLevel float64
+
+	// Chip register log, see SetRegisterLog
+	regLog   io.Writer
+	lastRegs [16]byte
+
+	// Debug overlay: grid and effect positions
+	debug bool
+
+	// Music visualizer, smoothed levels of the three chip voices
+	visualizer       bool
+	visualizerLevels [3]float64
+
+	// Ticks left showing the music position bar after a scrub
+	seekBarTicks int
+
+	// What to do when the tune ends, and whether it has
+	musicEnd      MusicEnd
+	musicFinished bool
+
+	// Music playing backward, see updateReverse
+	reversing bool
+
+	// Free camera over the demo picture: zoom, pan in screen pixels, and
+	// the last cursor position of a right button drag
+	camZoom            float64
+	camX, camY         float64
+	camDragging        bool
+	camDragX, camDragY float64
+
+	// Mute, keeping the volume to restore
+	muted         bool
+	unmutedVolume float64
+
+	// Settings last loaded or applied, see Settings
+	settings Settings
+
+	// Subtitles timed to the music, sorted by Ms
+	captions []Caption
+
+	// Cube projection
+	fov       float64
+	wireframe bool
+	glass     bool
+
+	// CRT post-processing of the demo frame
+	crt bool
+
+	// Hue rotation of the demo frame in radians, turning while hueCycle is
+	// on, through hueShader into hueCanvas, see SetHueShift
+	hueShift  float64
+	hueCycle  bool
+	hueShader *ebiten.Shader
+	hueCanvas *ebiten.Image
+
+	// Background key color for compositing, nil for none, see SetChromaKey
+	chromaKey color.Color
+
+	// Accessibility: damp the fast motion and flicker, see SetReducedMotion
+	reducedMotion bool
+
+	// Photosensitive-safe copper bars, see SetSafeFlash
+	safeFlash bool
+
+	// Freeze: all animation stops while the window stays responsive. With
+	// freezeAudio the music pauses too; audioFrozen records that the freeze
+	// paused it, so unfreezing only resumes music that was playing.
+	frozen      bool
+	freezeAudio bool
+	audioFrozen bool
+
+	// Power save while frozen, see SetPowerSave
+	powerSave bool
+	idle      bool   // ticking at idleTPS
+	idleDrawn bool   // the idle frame is on screen, Draw can skip
+	idleState string // state at the last wake, to wake on changes
+	wakeTicks int    // ticks left before the game may sleep
+	activeTPS int    // TPS to restore on wake
+	inputKeys []ebiten.Key
+
+	// Where the logical picture sits on the screen, from LayoutF: scaled
+	// by viewScale device pixels per logical one and centered in viewRect
+	view      ebiten.GeoM
+	viewRect  image.Rectangle
+	viewScale float64
+
+	// Logical-size layer for the overlays, scaled onto a high-DPI screen
+	frame *ebiten.Image
+
+	// Input
+	keys KeyBindings
+
+	// Gamepad (first connected pad, if any)
+	gamepadIDs []ebiten.GamepadID
+	gamepadID  ebiten.GamepadID
+	hasGamepad bool
+
+	// VBL counter
+	vbl int
+}
+
+type DMASprite struct {
+	x, y float64
+}
+
+// NewGame builds the demo from assets. It does not open a window or start
+// the game loop; pass the result to ebiten.RunGame, or use NewLoader to show
+// a progress bar while it is built.
+func NewGame(assets Assets) *Game {
+	return newGame(assets, func(float64) {})
+}
+
+// newGame builds the demo, reporting progress from 0 to 1 as it goes
+func newGame(assets Assets, progress func(float64)) *Game {
+	g := &Game{
+		state:           "intro",
+		width:           ScreenWidth,
+		height:          ScreenHeight,
+		introSpeed:      8,
+		scrollDir:       1,
+		spritePos:       make([]float64, nbCubes),
+		grabbed:         -1,
+		formationT:      1,
+		camZoom:         1,
+		vignette:        defaultVignette,
+		settings:        Settings{Volume: defaultVolume},
+		speedMultiplier: 1.0,
+		speed:           1.0,
+		fov:             defaultFOV,
+		step:            1,
+		crt:             true,
+		viewRect:        image.Rect(0, 0, ScreenWidth, ScreenHeight),
+		viewScale:       1,
+		bannerHeight:    defaultBannerHeight,
+		scrollShadowX:   scrollShadowOffset,
+		scrollShadowY:   scrollShadowOffset,
+		keys:            DefaultKeyBindings(),
+		dmaScale:        0.5,
+		dmaAlpha:        0.6,
+		logoCenter:      ScreenWidth / 2,
+		logoAmplitude:   ScreenWidth,
+		logoX:           0.5, // Center the logo (0.5 = centered)
+		hold:            0,   // Start immediately
+	}
+
+	// Load images
+	if len(assets.Icon) > 0 {
+		var err error
+		if g.icon, _, err = image.Decode(bytes.NewReader(assets.Icon)); err != nil {
+			log.Printf("Failed to load icon: %v", err)
+		}
+	}
+	g.titleImg = loadImage("title", assets.Title)
+	g.dmaLogoImg = loadImage("dma logo", assets.DMALogo)
+	barsImg := loadImage("bars", assets.Bars)
+	cocoImg := loadImage("coco", assets.Coco)
+	fontImg := loadImage("font", assets.Font)
+
+	progress(0.3)
+
+	// Init font
+	g.font = NewFont(fontImg)
+
+	// Init intro text
+	spc := "     "
+	g.SetIntroText(spc + spc + "IF YOU THINK THIS IS ALL, YOU'RE SO WRONG..." + spc)
+
+	// Init 3D cubes
+	g.cubes = make([]*Cube3D, nbCubes)
+	for i := 0; i < nbCubes; i++ {
+		g.cubes[i] = NewCube3D(cubeSize)
+		g.cubes[i].SetSpin(defaultCubeSpin(i))
+	}
+	g.resetCubes()
+
+	progress(0.4)
+
+	// Init DMA logo grid
+	g.SetDMAGrid(defaultDMARows, defaultDMACols)
+
+	// Init demo scroll text
+	g.scroller = NewScroller(g.font, spc+spc+"WELCOME TO THE COCO IS THE BEST DEMO! "+spc+
+		"THIS DEMO COMBINES THE BEST EFFECTS FROM VARIOUS ATARI ST DEMOS. "+spc+
+		"GREETINGS TO ALL DEMOSCENE LOVERS! "+spc+spc)
+
+	// Size the canvases and effects to the logical resolution
+	g.applyBounds()
+
+	progress(0.6)
+
+	// Init audio
+	g.initAudio(assets)
+
+	progress(0.7)
+
+	// Init copper bars and rotozoom
+	g.copper = NewCopperBars(barsImg)
+	g.roto = NewRotozoom(cocoImg)
+	g.rng = newRand(rand.Int64())
+	g.starfield = NewStarfield(starCount, g.rng)
+	g.layers = g.defaultLayers()
+
+	progress(0.8)
+
+	// Compile CRT shader
+	var err error
+	g.crtShader, err = ebiten.NewShader([]byte(crtShaderSrc))
+	if err != nil {
+		log.Printf("Failed to compile CRT shader: %v", err)
+		if line := shaderErrorSource(crtShaderSrc, err); line != "" {
+			log.Printf("CRT shader error at %s", line)
+		}
+		g.shaderNotice = shaderNoticeTicks
+	}
+	g.compileHueShader()
+
+	// The adjustments made on the last run
+	g.loadSettings()
+	progress(1)
+
+	return g
+}
+
+// Shutdown saves the settings, stops the music and frees the audio
+// resources. The audio player is closed before the decoder it reads from, so
+// its goroutine never reads a destroyed player. Call it once the game loop
+// has returned.
+func (g *Game) Shutdown() {
+	g.saveSettings()
+	if g.audioPlayer != nil {
+		g.audioPlayer.Pause()
+		if err := g.audioPlayer.Close(); err != nil {
+			log.Printf("Failed to close audio player: %v", err)
+		}
+		g.audioPlayer = nil
+	}
+	if g.music != nil {
+		g.music.Close()
+		g.music = nil
+	}
+}
+
+// WindowIcon returns the decoded Assets.Icon for ebiten.SetWindowIcon, or nil
+// when there is none or it failed to decode
+func (g *Game) WindowIcon() []image.Image {
+	if g.icon == nil {
+		return nil
+	}
+	return []image.Image{g.icon}
+}
+
+// defaultCubeSpin is the spin of cube i: every cube turns a little faster
+// than the previous one, so the formation never rotates in lockstep
+func defaultCubeSpin(i int) (dx, dy, dz float64) {
+	return 0.02 * (1 + float64(i)*0.1),
+		0.03 * (1 + float64(i)*0.15),
+		0.01 * (1 + float64(i)*0.05)
+}
+
+// SetCubeSpin sets the per-tick rotation of cube i around each axis, in
+// radians; the speed multiplier and reduced motion still scale it
+func (g *Game) SetCubeSpin(i int, dx, dy, dz float64) {
+	if i < 0 || i >= len(g.cubes) {
+		return
+	}
+	g.cubes[i].SetSpin(dx, dy, dz)
+}
+
+// resetCubes puts the cubes back at their starting positions and rotations
+func (g *Game) resetCubes() {
+	for i, c := range g.cubes {
+		// Set initial position offset for each cube
+		g.spritePos[i] = float64(0.15) * float64(i+1)
+		// Set different initial rotations
+		c.angleX = float64(i) * 0.3
+		c.angleY = float64(i) * 0.2
+		c.angleZ = float64(i) * 0.1
+	}
+}
+
+// Reset restarts the demo from the beginning of the intro with the music
+// rewound, as if it had just been launched. Settings such as speed, FOV,
+// volume and the toggles are kept.
+func (g *Game) Reset() {
+	if g.audioPlayer != nil {
+		g.audioPlayer.Pause()
+		if err := g.audioPlayer.Rewind(); err != nil {
+			log.Printf("Failed to rewind music: %v", err)
+		}
+	}
+
+	g.state = "intro"
+	g.introComplete = false
+	g.iteration = 0
+	g.vbl = 0
+	g.frozen = false
+	g.audioFrozen = false
+
+	g.resetIntro()
+
+	// Demo effects
+	g.scroller.Reset()
+	g.copper.Reset()
+	g.roto.Reset()
+	g.resetCubes()
+	g.grabbed = -1
+	g.cubePulsePhase = 0
+	g.formation = FormationWave
+	g.formationT = 1
+	g.beatCooldown = 0
+	g.ctrSprite = 0
+	g.logoX = 0.5
+	g.hold = 0
+	g.audioLevel = 0
+}
+
+// loadImage decodes an embedded image, logging and returning nil on failure
+func loadImage(name string, data []byte) *ebiten.Image {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Failed to load %s: %v", name, err)
+		return nil
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+func (g *Game) initAudio(assets Assets) {
+	g.audioContext = audio.NewContext(sampleRate)
+
+	var err error
+	if assets.MusicPath != "" {
+		g.music, err = loadMusicFile(assets.MusicPath)
+		if err != nil {
+			log.Printf("Failed to load %s, using the built-in music: %v", assets.MusicPath, err)
+		}
+	}
+	if g.music == nil {
+		g.music, err = NewChipPlayer("music.ym", assets.Music, sampleRate, true)
+		if err != nil {
+			log.Printf("Failed to create music player: %v", err)
+			return
+		}
+	}
+
+	// An optional second tune, mixed in through a crossfader
+	var second ChipPlayer
+	if assets.SecondMusicPath != "" {
+		if second, err = loadMusicFile(assets.SecondMusicPath); err != nil {
+			log.Printf("Failed to load %s: %v", assets.SecondMusicPath, err)
+		}
+	} else if len(assets.SecondMusic) > 0 {
+		if second, err = NewChipPlayer("music2.ym", assets.SecondMusic, sampleRate, true); err != nil {
+			log.Printf("Failed to create second music player: %v", err)
+		}
+	}
+	if second != nil {
+		second.SetVolume(g.music.GetVolume())
+		g.music = NewCrossfader(g.music, second, sampleRate)
+	}
+
+	g.audioPlayer, err = g.audioContext.NewPlayer(g.music)
+	if err != nil {
+		log.Printf("Failed to create audio player: %v", err)
+		g.music.Close()
+		g.music = nil
+		return
+	}
+
+	// Music will start when transitioning from intro to demo phase
+}
+
+// loadMusicFile reads a tune from disk into a looping player chosen from its
+// extension. The tune is parsed up front, so a corrupt file is rejected here
+// rather than silencing the demo later.
+func loadMusicFile(path string) (ChipPlayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewChipPlayer(path, data, sampleRate, true)
+}
+
+// SetBounds sets the logical size the demo renders at, for embedding it as
+// a scene of another game. Layout returns it and every canvas is recreated
+// to match; the title logo swing goes back to its default for the new width.
+func (g *Game) SetBounds(w, h int) {
+	if w < 1 || h <= g.bannerHeight || (w == g.width && h == g.height) {
+		return
+	}
+	g.width, g.height = w, h
+	g.logoCenter = float64(w) / 2
+	g.logoAmplitude = float64(w)
+	g.applyBounds()
+}
+
+// applyBounds derives everything sized from the logical resolution: the
+// offscreen canvases, the title scale and the scroller width. It is the one
+// place these are computed, so any aspect ratio works, not just 4:3; the
+// remaining placement (cube orbit, DMA grid, copper sweep) is scaled from
+// g.width and g.height where it is drawn.
+func (g *Game) applyBounds() {
+	for _, img := range []*ebiten.Image{g.frame, g.introCanvas, g.mainCanvas, g.introRing, g.introStrip, g.titleCanvas, g.hueCanvas} {
+		if img != nil {
+			img.Deallocate()
+		}
+	}
+	g.frame = ebiten.NewImage(g.width, g.height)
+	g.introCanvas = ebiten.NewImage(g.width, g.height)
+	g.mainCanvas = ebiten.NewImage(g.width, g.height)
+	g.hueCanvas = ebiten.NewImage(g.width, g.height)
+	g.introRing = ebiten.NewImage(g.width+introRingMargin, int(fontHeight*2))
+	g.introStrip = ebiten.NewImage(g.width, int(fontHeight*2))
+	g.resetIntro()
+	g.titleCanvas = ebiten.NewImage(g.width, g.bannerHeight)
+
+	g.updateTitleScale()
+	g.scroller.SetWidth(g.width)
+}
+
+// SetBannerHeight resizes the title banner. The copper bars fill it (one bar
+// per 2 rows), the logo is scaled to it and the scroller fills the rest.
+func (g *Game) SetBannerHeight(h int) {
+	if h < 2 || h > g.height || h == g.bannerHeight {
+		return
+	}
+	g.bannerHeight = h
+	g.titleCanvas.Deallocate()
+	g.titleCanvas = ebiten.NewImage(g.width, h)
+	g.updateTitleScale()
+}
+
+// SetLogoSwing sets where the title logo rests and how far it swings either
+// side, in pixels. A small amplitude gives a gentle ping-pong; a large one is
+// limited so part of the logo always stays on screen.
+func (g *Game) SetLogoSwing(center, amplitude float64) {
+	g.logoCenter = center
+	g.logoAmplitude = math.Abs(amplitude)
+}
+
+// SetDMAGrid arranges the DMA logos in rows x cols, e.g. 4x2 or a single row
+func (g *Game) SetDMAGrid(rows, cols int) {
+	if rows < 1 || cols < 1 {
+		return
+	}
+	g.dmaRows = rows
+	g.dmaCols = cols
+	g.dmaSprites = make([]DMASprite, rows*cols)
+}
+
+// SetDMAPulse makes the DMA logos breathe: scale and alpha swing by amount
+// (0.1 = ±10%) at speed radians per frame, or with the music level when
+// audio is set. An amount of 0 keeps them static.
+func (g *Game) SetDMAPulse(amount, speed float64, audio bool) {
+	g.dmaPulse = amount
+	g.dmaPulseSpeed = speed
+	g.dmaPulseAudio = audio
+}
+
+// SetCubePulse makes the cubes breathe: their size swings by amount (0.2 =
+// ±20%) at speed radians per tick, each cube a little behind the one before
+// so the pulse ripples through them. An amount of 0 keeps them at full size.
+func (g *Game) SetCubePulse(amount, speed float64) {
+	g.cubePulse = amount
+	g.cubePulseSpeed = speed
+}
+
+// SetDMAIndependent lets each DMA logo follow the path with its own phase,
+// swirling in and out of the grid, instead of moving as one rigid block
+func (g *Game) SetDMAIndependent(on bool) {
+	g.dmaIndependent = on
+}
+
+// updateTitleScale picks the title logo scale: fit to the banner height,
+// keeping the logo's proportions, or fit to the screen width when that
+// would make it wider than the screen.
+func (g *Game) updateTitleScale() {
+	if g.titleImg == nil {
+		return
+	}
+	titleW := float64(g.titleImg.Bounds().Dx())
+	titleH := float64(g.titleImg.Bounds().Dy())
+	g.titleScale = float64(g.bannerHeight) / titleH
+	if titleW*g.titleScale > float64(g.width) {
+		g.titleScale = float64(g.width) / titleW
+	}
+}
+
+// SetReducedMotion turns the accessibility mode on or off. It affects only:
+//   - cube rotation: a third of the normal spin
+//   - copper bars: a third of the normal sweep speed, no music throb
+//   - title logo: a quarter of the normal swing speed
+//   - CRT shader: no scanlines and no red/blue color fringing
+//
+// The scroller, rotozoom and DMA logos keep their normal motion.
+func (g *Game) SetReducedMotion(on bool) {
+	g.reducedMotion = on
+	g.copper.Speed = 1.0
+	if on {
+		g.copper.Speed = 1.0 / 3
+	}
+}
+
+// SetSafeFlash keeps the copper bars under the WCAG general flash threshold:
+// their motion is low-pass filtered and they are dimmed so the banner never
+// changes by a full flash step. See CopperBars.Safe for the measured rates.
+func (g *Game) SetSafeFlash(on bool) {
+	g.safeFlash = on
+	g.copper.Safe = on
+}
+
+// SetRotozoomTint gives the rotozoom background a color cast. tint is the
+// color white tiles turn into, brightness scales it further (1 = as is).
+// The default is 50% gray.
+func (g *Game) SetRotozoomTint(tint color.RGBA, brightness float64) {
+	scale := func(c uint8) uint8 {
+		return uint8(math.Max(0, math.Min(float64(c)*brightness, 0xff)))
+	}
+	g.roto.Tint = color.RGBA{scale(tint.R), scale(tint.G), scale(tint.B), tint.A}
+}
+
+// SetRotozoomFrozen holds the rotozoom still, leaving every other effect
+// moving, for when its motion distracts from tuning the others
+func (g *Game) SetRotozoomFrozen(on bool) {
+	g.rotozoomFrozen = on
+}
+
+// SetRotozoomSmooth filters the rotozoom tile linearly, with mipmaps when it
+// is zoomed out, against shimmering. Off by default, keeping the pixel look.
+func (g *Game) SetRotozoomSmooth(on bool) {
+	g.rotozoomSmooth = on
+	g.roto.Smooth = on
+}
+
+// SetStarfield shows or hides the starfield behind the rotozoom. While it is
+// shown the rotozoom is drawn translucent so the stars come through.
+func (g *Game) SetStarfield(on bool) {
+	g.stars = on
+	g.roto.Opacity = 1
+	if on {
+		g.roto.Opacity = starfieldRotoOpacity
+	}
+}
+
+// SetLetterSpacing spaces the glyphs px font pixels apart, in the megatwist
+// scroller and in the text overlays (captions and notices). Negative values
+// are limited like the scroller's, see Scroller.SetLetterSpacing.
+func (g *Game) SetLetterSpacing(px int) {
+	g.scroller.SetLetterSpacing(px)
+	g.letterSpacing = g.scroller.letterSpacing
+}
+
+// SetScrollDirection makes the megatwist text scroll forward (+1, the
+// default) or backward (-1)
+func (g *Game) SetScrollDirection(dir int) {
+	g.scrollDir = 1
+	if dir < 0 {
+		g.scrollDir = -1
+	}
+	g.scroller.SetDirection(g.scrollDir)
+}
+
+// SetFontSmooth draws the 2x intro and 3x scroller glyphs with linear
+// filtering for a smoother look. Off by default, keeping the pixel look.
+func (g *Game) SetFontSmooth(on bool) {
+	g.fontSmooth = on
+	g.scroller.SetSmooth(on)
+}
+
+// SetScrollShadow draws a dark drop shadow under the scroll text, to read
+// it more easily over the rotozoom
+func (g *Game) SetScrollShadow(on bool) {
+	g.scrollShadow = on
+	g.scroller.SetShadow(on, g.scrollShadowX, g.scrollShadowY)
+}
+
+// SetScrollShadowOffset moves the scroll text's shadow dx, dy pixels from
+// the text, 3, 3 by default
+func (g *Game) SetScrollShadowOffset(dx, dy int) {
+	g.scrollShadowX, g.scrollShadowY = dx, dy
+	g.scroller.SetShadow(g.scrollShadow, dx, dy)
+}
+
+// SetScrollWrapGap sets the blank gap, in pixels, between the end of the
+// scroll text and its restart. Negative means one screen width, the default.
+func (g *Game) SetScrollWrapGap(px int) {
+	g.scroller.SetWrapGap(px)
+}
+
+// SetCopperPalette recolors the banner copper bars. A nil or empty palette
+// goes back to the stripes of bars.png.
+func (g *Game) SetCopperPalette(palette []color.Color) {
+	g.copper.Palette = palette
+}
+
+// SetCopperPhases sets how far apart the banner copper bars sit on the two
+// sine phases, in table entries per bar. The defaults are 7 and 10.
+func (g *Game) SetCopperPhases(a, b int) {
+	g.copper.PhaseA = a
+	g.copper.PhaseB = b
+}
+
+func (g *Game) getIntroLetter(pos int) rune {
+	if len(g.introRunes) == 0 {
+		return ' '
+	}
+	return g.introRunes[pos%len(g.introRunes)]
+}
+
+// Fastest intro scroll, in pixels per 60 TPS tick: the narrowest glyph at 2x
+const introMaxSpeed = 32
+
+// How much wider the intro ring is than the screen: the widest glyph at 2x,
+// so a letter is drawn over one that has fully left the screen
+const introRingMargin = 96
+
+// SetIntroText sets the line the intro scrolls before the demo starts. An
+// empty text skips the intro. Set during the intro, it starts over.
+func (g *Game) SetIntroText(text string) {
+	g.introText = text
+	g.introRunes = fontRunes(g.font, text)
+	if g.state == "intro" {
+		g.resetIntro()
+	}
+}
+
+// SetIntroSpeed sets how many pixels the intro text moves per 60 TPS tick,
+// clamped to 1..introMaxSpeed. The default is 8.
+func (g *Game) SetIntroSpeed(px int) {
+	g.introSpeed = max(1, min(px, introMaxSpeed))
+}
+
+// resetIntro rewinds the intro scroller to before its first letter
+func (g *Game) resetIntro() {
+	g.introScroll = 0
+	g.introNext = 0
+	g.introPen = 0
+	if g.introRing != nil {
+		g.introRing.Clear()
+	}
+}
+
+func (g *Game) Update() error {
+	// Fullscreen toggle
+	if inpututil.IsKeyJustPressed(g.keys.Fullscreen) {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+	}
+
+	g.updateCamera()
+
+	g.updateReverse()
+
+	// Music scrubbing
+	if g.state == "demo" {
+		if inpututil.IsKeyJustPressed(g.keys.SeekBack) {
+			g.seekMusic(-seekStep)
+		}
+		if inpututil.IsKeyJustPressed(g.keys.SeekForward) {
+			g.seekMusic(seekStep)
+		}
+	}
+
+	g.updateGamepad()
+
+	// Volume control
+	if g.music != nil {
+		if ebiten.IsKeyPressed(g.keys.VolumeUp) || g.gamepadPressed(ebiten.StandardGamepadButtonLeftTop) {
+			g.setVolume(g.volume() + 0.01)
+		}
+		if ebiten.IsKeyPressed(g.keys.VolumeDown) || g.gamepadPressed(ebiten.StandardGamepadButtonLeftBottom) {
+			g.setVolume(g.volume() - 0.01)
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Mute) {
+		g.toggleMute()
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.SwitchTrack) {
+		if x, ok := g.music.(*Crossfader); ok {
+			x.Switch()
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Visualizer) {
+		g.visualizer = !g.visualizer
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Debug) {
+		g.debug = !g.debug
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.ScrollDirection) {
+		g.SetScrollDirection(-g.scrollDir)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Freeze) {
+		g.setFrozen(!g.frozen)
+	}
+	if inpututil.IsKeyJustPressed(g.keys.FreezeAudio) {
+		g.freezeAudio = !g.freezeAudio
+	}
+
+	// Cube face rasterizer
+	if inpututil.IsKeyJustPressed(g.keys.CubeAA) {
+		for _, c := range g.cubes {
+			c.Antialias = !c.Antialias
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Wireframe) {
+		g.wireframe = !g.wireframe
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.BeatFormations) {
+		g.SetBeatFormations(!g.beatFormations)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Glass) {
+		g.glass = !g.glass
+	}
+
+	g.updateLayerKeys()
+	if inpututil.IsKeyJustPressed(g.keys.RotozoomFreeze) {
+		g.SetRotozoomFrozen(!g.rotozoomFrozen)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.Starfield) {
+		g.SetStarfield(!g.stars)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.CRT) {
+		g.crt = !g.crt
+	}
+	if inpututil.IsKeyJustPressed(g.keys.HueCycle) {
+		g.SetHueCycle(!g.hueCycle)
+	}
+	if inpututil.IsKeyJustPressed(g.keys.HueStep) {
+		g.SetHueShift(g.hueShift + hueStep)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.VignetteUp) {
+		g.SetVignette(g.vignette + vignetteStep)
+	}
+	if inpututil.IsKeyJustPressed(g.keys.VignetteDown) {
+		g.SetVignette(g.vignette - vignetteStep)
+	}
+
+	if inpututil.IsKeyJustPressed(g.keys.ReducedMotion) {
+		g.SetReducedMotion(!g.reducedMotion)
+	}
+
+	// Perspective, changed gradually while held
+	if ebiten.IsKeyPressed(g.keys.FOVUp) {
+		g.fov = math.Min(g.fov+2, maxFOV)
+	}
+	if ebiten.IsKeyPressed(g.keys.FOVDown) {
+		g.fov = math.Max(g.fov-2, minFOV)
+	}
+
+	// Speed control
+	if inpututil.IsKeyJustPressed(g.keys.SpeedUp) || g.gamepadJustPressed(ebiten.StandardGamepadButtonFrontTopRight) {
+		g.speedMultiplier += 0.1
+		if g.speedMultiplier > 2.0 {
+			g.speedMultiplier = 2.0
+		}
+	}
+	if inpututil.IsKeyJustPressed(g.keys.SpeedDown) || g.gamepadJustPressed(ebiten.StandardGamepadButtonFrontTopLeft) {
+		g.speedMultiplier -= 0.1
+		if g.speedMultiplier < 0.5 {
+			g.speedMultiplier = 0.5
+		}
+	}
+
+	// Restart from the intro
+	if inpututil.IsKeyJustPressed(g.keys.Reset) {
+		g.Reset()
+	}
+
+	// Skip intro
+	if g.state == "intro" && g.gamepadJustPressed(ebiten.StandardGamepadButtonRightBottom) {
+		g.startDemo()
+	}
+
+	// All animation state moves in updateIntro and updateDemo, so skipping
+	// them freezes the frame and picks up exactly where it stopped
+	g.step = tickStep()
+	switch {
+	case g.frozen:
+		// Nothing moves until unfrozen
+	case g.state == "intro":
+		g.updateIntro()
+	default:
+		g.updateDemo()
+	}
+	g.updateVisualizer()
+	g.logRegisters()
+	g.updateShaderNotice()
+	g.updateSeekBar()
+	g.updateMusicEnd()
+	g.updatePowerSave()
+
+	g.vbl++
+	return nil
+}
+
+// setFrozen freezes or unfreezes the animation. The music keeps playing
+// unless freezeAudio is on, in which case it pauses with the picture.
+func (g *Game) setFrozen(on bool) {
+	g.frozen = on
+	if g.audioPlayer == nil {
+		return
+	}
+	if on && g.freezeAudio && g.audioPlayer.IsPlaying() {
+		g.audioPlayer.Pause()
+		g.audioFrozen = true
+	} else if !on && g.audioFrozen {
+		g.audioPlayer.Play()
+		g.audioFrozen = false
+	}
+}
+
+// tickStep returns how many 60 TPS ticks the current tick stands for, so the
+// demo keeps the same perceived speed when the TPS is changed. It follows the
+// TPS setting, or ActualTPS with SyncWithFPS, clamped to 15..240. The setting
+// rather than the measured rate is used so that waking from power save, when
+// ActualTPS still averages the idle ticks, doesn't make everything lurch.
+func tickStep() float64 {
+	tps := float64(ebiten.TPS())
+	if tps <= 0 {
+		tps = ebiten.ActualTPS()
+	}
+	if tps <= 0 {
+		return 1
+	}
+	tps = math.Max(15, math.Min(tps, 240))
+	return baseTPS / tps
+}
+
+// Fraction of the way to the target speed covered per 60 TPS tick: 90% of
+// a change is done in a quarter of a second
+const speedEase = 0.15
+
+// easeSpeed moves the speed in use toward the target over step ticks,
+// settling exactly on it once close, so a key press doesn't snap the motion
+func easeSpeed(speed, target, step float64) float64 {
+	speed = target + (speed-target)*math.Pow(1-speedEase, step)
+	if math.Abs(target-speed) < 0.001 {
+		return target
+	}
+	return speed
+}
+
+// SkipIntro starts straight in the demo with the music playing, as if the
+// intro had already scrolled by. Reset still goes back to the intro.
+func (g *Game) SkipIntro() {
+	if g.state == "intro" {
+		g.startDemo()
+	}
+}
+
+// startDemo leaves the intro and starts the music
+func (g *Game) startDemo() {
+	g.introComplete = true
+	g.state = "demo"
+	g.iteration = 0
+	g.startMusic()
+}
+
+// startMusic plays the music unless it is already playing, so however the
+// intro is left the tune starts once
+func (g *Game) startMusic() {
+	if g.audioPlayer != nil && !g.audioPlayer.IsPlaying() {
+		g.audioPlayer.Play()
+	}
+}
+
+// updateIntro scrolls the intro line in from the right, introSpeed pixels
+// per 60 TPS tick whatever the TPS. Each letter is drawn into the ring once,
+// as it reaches the right edge; the demo starts once the last one is in.
+func (g *Game) updateIntro() {
+	if g.introNext >= len(g.introRunes) && g.introScroll >= float64(g.introPen) {
+		g.startDemo()
+		return
+	}
+	g.introScroll += float64(g.introSpeed) * g.step
+
+	for g.introNext < len(g.introRunes) && float64(g.introPen) < g.introScroll {
+		if letter, ok := g.font.Letter(g.getIntroLetter(g.introNext)); ok {
+			g.drawIntroLetter(letter, g.introPen)
+			g.introPen += letter.width * 2
+		}
+		g.introNext++
+	}
+}
+
+// drawIntroLetter draws a letter at 2x into the ring at line position x,
+// over whatever letter was there a ring width earlier. A letter crossing
+// the end of the ring is drawn again at its start.
+func (g *Game) drawIntroLetter(letter *Letter, x int) {
+	ringW := g.introRing.Bounds().Dx()
+	w, h := letter.width*2, int(fontHeight*2)
+	x %= ringW
+
+	op := &ebiten.DrawImageOptions{}
+	if g.fontSmooth {
+		op.Filter = ebiten.FilterLinear
+	}
+	for _, at := range []int{x, x - ringW} {
+		if at+w <= 0 || at >= ringW {
+			continue
+		}
+		g.introRing.SubImage(image.Rect(at, 0, at+w, h)).(*ebiten.Image).Clear()
+		op.GeoM.Reset()
+		op.GeoM.Scale(2.0, 2.0)
+		op.GeoM.Translate(float64(at), 0)
+		g.introRing.DrawImage(g.font.Glyph(letter), op)
+	}
+}
+
+// composeIntro copies the visible window of the ring into introStrip: the
+// line position at the left edge of the screen is introScroll less the
+// screen width, wrapped around the ring. The window stops at the end of
+// the letters drawn, past which the ring still holds old ones.
+func (g *Game) composeIntro() {
+	g.introStrip.Clear()
+	left := int(g.introScroll) - g.width
+	width := min(g.width, g.introPen-left)
+	h := g.introStrip.Bounds().Dy()
+	op := &ebiten.DrawImageOptions{}
+	wrapSegments(left, width, g.introRing.Bounds().Dx(), func(srcX, dstX, w int) {
+		op.GeoM.Reset()
+		op.GeoM.Translate(float64(dstX), 0)
+		g.introStrip.DrawImage(g.introRing.SubImage(image.Rect(srcX, 0, srcX+w, h)).(*ebiten.Image), op)
+	})
+}
+
+func (g *Game) updateDemo() {
+	g.iteration += g.step
+
+	// Update copper bars and scroller
+	g.copper.Amplitude = g.copperAmplitude()
+	g.copper.Update(g.step)
+	g.scroller.Update(g.step)
+
+	// Update 3D cubes, except the one held with the mouse
+	g.updateGrab()
+	g.speed = easeSpeed(g.speed, g.speedMultiplier, g.step)
+	spin := g.speed * g.step
+	if g.reducedMotion {
+		spin /= 3
+	}
+	for i := 0; i < nbCubes; i++ {
+		if i == g.grabbed {
+			continue
+		}
+		g.spritePos[i] += 0.04 * g.speed * g.step
+		g.cubes[i].Spin(spin)
+	}
+	g.updateFormation()
+
+	// Cube breathing, each cube a step further round the pulse
+	g.cubePulsePhase += g.cubePulseSpeed * g.step
+	for i, c := range g.cubes {
+		phase := g.cubePulsePhase - 2*math.Pi*float64(i)/nbCubes
+		c.SetSize(cubeSize * (1 + g.cubePulse*math.Sin(phase)))
+	}
+
+	// Update DMA logo sprites - synchronized movement (all move together)
+	// unless dmaIndependent gives each one its own phase
+	g.ctrSprite += 0.02 * g.step
+
+	// Base position centered on screen, avoiding the banner
+	centerX := float64(g.width) / 2
+	centerY := float64(g.playfieldTop()) + float64(g.height-g.bannerHeight)/2 // Centered in the space the banner leaves
+
+	spacingX, spacingY := g.dmaSpacing()
+
+	for i := range g.dmaSprites {
+		row := i / g.dmaCols
+		col := i % g.dmaCols
+
+		// Grid offsets, centered on the middle cell
+		offsetX := (float64(col) - float64(g.dmaCols-1)/2) * spacingX
+		offsetY := (float64(row) - float64(g.dmaRows-1)/2) * spacingY
+
+		// Each sprite trails the previous one along the path and the grid
+		// breathes in and out, so the logos swirl around the formation
+		t := g.ctrSprite
+		if g.dmaIndependent {
+			phase := float64(i) * 0.35
+			t -= phase
+			spread := 0.7 + 0.3*math.Cos(g.ctrSprite*0.8+phase)
+			offsetX *= spread
+			offsetY *= spread
+		}
+		baseX, baseY := dmaMotion(t)
+
+		g.dmaSprites[i].x = centerX + offsetX + baseX
+		g.dmaSprites[i].y = centerY + offsetY + baseY
+	}
+
+	// Update rotozoom, unless held, and the stars behind it
+	if !g.rotozoomFrozen {
+		g.roto.Speed = g.step
+		g.roto.Update()
+	}
+	g.updateHue()
+	if g.stars {
+		speed := g.speed * g.step
+		if g.reducedMotion {
+			speed /= 3
+		}
+		g.starfield.Update(speed)
+	}
+
+	// Update title logo (oscillating movement like viva_tcb)
+	if g.hold >= 1 {
+		g.hold--
+	}
+	if g.hold <= 0 {
+		if g.reducedMotion {
+			g.logoX += 0.0125 / 4 * g.step
+		} else {
+			g.logoX += 0.0125 * g.step // Moves from right to left and back
+		}
+	}
+}
+
+// dmaSpacing returns the distance between the DMA logo grid cells: the
+// default cells, shared out among the columns and rows of other grids and
+// stretched to the area the banner leaves
+func (g *Game) dmaSpacing() (x, y float64) {
+	x = dmaCellWidth * defaultDMACols / float64(g.dmaCols) * float64(g.width) / ScreenWidth
+	y = dmaCellHeight * defaultDMARows / float64(g.dmaRows) *
+		float64(g.height-g.bannerHeight) / (ScreenHeight - defaultBannerHeight)
+	return x, y
+}
+
+// dmaMotion is the Lissajous path the DMA logo grid follows at time t
+func dmaMotion(t float64) (x, y float64) {
+	x = 100*math.Sin(t*1.35+1.25) + 100*math.Sin(t*1.86+0.54)
+	y = 60*math.Cos(t*1.72+0.23) + 60*math.Cos(t*1.63+0.98)
+	return x, y
+}
+
+// copperAmplitude makes the copper bars throb with the music. The RMS level
+// goes through an envelope follower (instant attack, slow release) so beats
+// kick the bars outward and they settle back between them. Without music
+// playing the bars keep their original swing.
+func (g *Game) copperAmplitude() float64 {
+	if g.music == nil || g.audioPlayer == nil || !g.audioPlayer.IsPlaying() {
+		g.audioLevel = 0
+		return 1.0
+	}
+	if g.reducedMotion {
+		return 1.0
+	}
+
+	// Chip music rarely goes past a quarter of full scale
+	_, rms := g.music.GetLevels()
+	level := math.Min(rms/0.25, 1.0)
+	if level > g.audioLevel {
+		g.audioLevel = level
+	} else {
+		g.audioLevel *= 0.92
+	}
+
+	return 0.6 + 0.8*g.audioLevel
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	// Nothing moves while idle and the screen is kept, see setIdle
+	if g.idle && g.idleDrawn {
+		return
+	}
+	g.idleDrawn = g.idle
+
+	// On a high-DPI display the screen is in device pixels (see LayoutF).
+	// The effects are composed at the logical size and drawn through the
+	// view, scaled to fit with nearest filtering so the bitmap font stays
+	// sharp, and the CRT pass runs at device resolution.
+	// The overlays are drawn on a logical layer scaled the same way.
+	view, picture := g.view, g.viewRect
+	overlay := g.frame
+	if screen.Bounds().Dx() == g.width && screen.Bounds().Dy() == g.height {
+		view, picture = ebiten.GeoM{}, screen.Bounds()
+		overlay = screen
+	} else {
+		screen.Fill(color.Black)
+		overlay.Clear()
+	}
+	dst := screen.SubImage(picture).(*ebiten.Image)
+	dst.Fill(g.background())
+
+	if g.state == "intro" {
+		g.drawIntro(dst, view)
+	} else {
+		g.drawDemo(dst, view)
+	}
+
+	if g.visualizer && g.state == "demo" {
+		g.drawVisualizer(overlay)
+	}
+	if g.muted {
+		g.drawMuteIcon(overlay)
+	}
+	g.drawMusicEnd(overlay)
+	g.drawShaderNotice(overlay)
+
+	if overlay != screen {
+		op := &ebiten.DrawImageOptions{GeoM: view, Filter: ebiten.FilterNearest}
+		dst.DrawImage(overlay, op)
+	}
+}
+
+// drawIntro draws the intro strip across the middle of dst, placing the
+// logical screen on it with view
+func (g *Game) drawIntro(dst *ebiten.Image, view ebiten.GeoM) {
+	g.introCanvas.Fill(color.Black)
+
+	g.composeIntro()
+
+	var geoM ebiten.GeoM
+	geoM.Translate(0, float64(g.height/2-int(fontHeight*2)/2))
+	geoM.Concat(view)
+
+	if g.crtShader != nil {
+		op := &ebiten.DrawRectShaderOptions{GeoM: geoM}
+		op.Images[0] = g.introStrip
+		op.Uniforms = g.crtOptions(int(fontHeight * 2)).uniforms()
+		dst.DrawRectShader(g.width, int(fontHeight*2), g.crtShader, op)
+	} else {
+		op := &ebiten.DrawImageOptions{GeoM: geoM}
+		dst.DrawImage(g.introStrip, op)
+	}
+}
+
+// drawDemo composes the effects on mainCanvas and draws it to dst, placing
+// the logical screen on it with view
+func (g *Game) drawDemo(dst *ebiten.Image, view ebiten.GeoM) {
+	g.mainCanvas.Fill(g.demoBackground())
+
+	// Order of rendering (back to front):
+	// 0. Starfield, if on (furthest back)
+	if g.stars {
+		g.starfield.Draw(g.mainCanvas)
+	}
+
+	// 1-5. The effects, by default rotozoom background, scrolling text,
+	// DMA logo grid, 3D cubes, then the title logo with copper bars on top
+	g.drawLayers(g.mainCanvas)
+
+	// 6. Music position, along the banner
+	g.drawSeekBar(g.mainCanvas)
+
+	// 7. Captions timed to the music
+	g.drawCaption(g.mainCanvas)
+
+	// 8. Debug overlay, last so it sits over everything
+	if g.debug {
+		g.drawDebug(g.mainCanvas)
+	}
+
+	// The hue rotation, if any, then the whole frame goes through the CRT
+	// shader, so the curvature and vignette span the full screen rather
+	// than the intro's thin strip
+	frame := g.hueFrame(g.mainCanvas)
+	geoM := g.cameraGeoM()
+	geoM.Concat(view)
+	if g.crt && g.crtShader != nil {
+		op := &ebiten.DrawRectShaderOptions{GeoM: geoM}
+		op.Images[0] = frame
+		op.Uniforms = g.crtOptions(g.height).uniforms()
+		dst.DrawRectShader(g.width, g.height, g.crtShader, op)
+		return
+	}
+
+	op := &ebiten.DrawImageOptions{GeoM: geoM}
+	dst.DrawImage(frame, op)
+}
+
+func (g *Game) drawDMALogos(dst *ebiten.Image) {
+	if g.dmaLogoImg == nil {
+		return
+	}
+
+	logoW := float64(g.dmaLogoImg.Bounds().Dx())
+	logoH := float64(g.dmaLogoImg.Bounds().Dy())
+
+	// Breathing: a sine over iteration, or the music envelope
+	pulse := g.dmaPulse * math.Sin(g.iteration*g.dmaPulseSpeed)
+	if g.dmaPulseAudio {
+		pulse = g.dmaPulse * (2*g.audioLevel - 1)
+	}
+	scale := g.dmaScale * (1 + pulse)
+	alpha := math.Min(g.dmaAlpha*(1+pulse), 1)
+
+	op := &ebiten.DrawImageOptions{}
+	for _, sprite := range g.dmaSprites {
+		op.GeoM.Reset()
+		op.ColorScale.Reset()
+		op.GeoM.Translate(-logoW/2, -logoH/2)
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(sprite.x, sprite.y)
+		op.ColorScale.Scale(1, 1, 1, float32(alpha)) // Semi-transparent
+		dst.DrawImage(g.dmaLogoImg, op)
+	}
+}
+
+// drawScrollText draws the scroller over the space the banner leaves
+func (g *Game) drawScrollText(dst *ebiten.Image) {
+	g.scroller.Draw(dst, g.playfieldTop(), g.height-g.bannerHeight)
+}
+
+func (g *Game) draw3DCubes(dst *ebiten.Image) {
+	// Draw each cube at its position
+	for i := 0; i < nbCubes; i++ {
+		xPos, yPos := g.cubePosition(i)
+
+		// Draw the 3D cube
+		g.cubes[i].Perspective = g.fov
+		g.cubes[i].Wireframe = g.wireframe
+		g.cubes[i].Palette = g.cubeColors(i)
+		g.cubes[i].FaceAlpha = 1.0
+		if g.glass {
+			g.cubes[i].FaceAlpha = 0.45
+		}
+		g.cubes[i].Draw(dst, xPos, yPos)
+	}
+}
+
+func (g *Game) drawTitleWithCopperbars(dst *ebiten.Image) {
+	if g.titleImg == nil {
+		return
+	}
+
+	// Fill title canvas with black (banner background)
+	g.titleCanvas.Fill(color.Black)
+
+	// Draw copper bars FIRST (background) - they will show through black/transparent areas of logo
+	g.copper.Draw(g.titleCanvas)
+
+	// Draw title logo on top with oscillating movement
+	titleW := float64(g.titleImg.Bounds().Dx()) * g.titleScale
+	titleH := float64(g.titleImg.Bounds().Dy()) * g.titleScale
+	titleX := g.logoCenter - titleW/2 + g.logoSwing(titleW)*math.Cos(g.logoX)
+	titleY := (float64(g.bannerHeight) - titleH) / 2
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(g.titleScale, g.titleScale)
+	op.GeoM.Translate(titleX, titleY)
+	g.titleCanvas.DrawImage(g.titleImg, op)
+
+	// Draw title canvas at the top of the screen, or the bottom
+	op.GeoM.Reset()
+	op.GeoM.Translate(0, float64(g.bannerTop()))
+	dst.DrawImage(g.titleCanvas, op)
+}
+
+// scanlineCount returns the CRT scanline pairs for a source h logical pixels
+// tall. The CRT pass is drawn at device resolution (see setView), so the
+// source covers h*viewScale device rows and each pair gets one dark and one
+// light row: the lines stay one device pixel apart at any window size or
+// device scale factor.
+func (g *Game) scanlineCount(h int) float32 {
+	if g.reducedMotion {
+		return 0
+	}
+	return float32(float64(h) * g.viewScale / 2)
+}
+
+// logoSwing returns the logo amplitude, reduced so that at both ends of the
+// swing at least logoMinVisible of a logo titleW wide is still on screen
+func (g *Game) logoSwing(titleW float64) float64 {
+	left := g.logoCenter - titleW/2
+	visible := titleW * logoMinVisible
+	maxLeft := left - (visible - titleW)
+	maxRight := float64(g.width) - visible - left
+	return math.Max(0, math.Min(g.logoAmplitude, math.Min(maxLeft, maxRight)))
+}
+
+// rgbShift returns the CRT color fringing, off in reduced motion mode
+func (g *Game) rgbShift() float32 {
+	if g.reducedMotion {
+		return 0
+	}
+	return 0.002
+}
+
+// Layout is never called by Ebiten, which uses LayoutF since the game has
+// one; it is kept for ebiten.Game and reports the logical size, 800x600
+// unless SetBounds changed it, with the picture filling it unscaled.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	g.setView(g.width, g.height)
+	return g.width, g.height
+}
+
+// LayoutF is used instead of Layout by Ebiten. It asks for a screen the size
+// of the window in device pixels, so a 2x display gets a device-resolution
+// screen instead of an 800x600 one upscaled with filtering. Draw places the
+// logical picture on it, see setView.
+func (g *Game) LayoutF(outsideWidth, outsideHeight float64) (float64, float64) {
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	w, h := math.Ceil(outsideWidth*scale), math.Ceil(outsideHeight*scale)
+	if w < 1 || h < 1 {
+		return float64(g.width), float64(g.height)
+	}
+	g.setView(int(w), int(h))
+	return w, h
+}
+
+// setView fits the logical picture on a screen w by h device pixels,
+// scaled to fill it on the limiting axis and centered with black bars on
+// the other, so a 16:9 display gets pillarboxing instead of a stretched 4:3
+// picture. When a whole scale factor fills the screen to within a device
+// pixel it is used instead, so every logical pixel covers the same number
+// of device pixels.
+func (g *Game) setView(w, h int) {
+	scale := math.Min(float64(w)/float64(g.width), float64(h)/float64(g.height))
+	if whole := math.Floor(scale); whole >= 1 && (float64(w)-whole*float64(g.width) <= 1 || float64(h)-whole*float64(g.height) <= 1) {
+		scale = whole
+	}
+	pw := int(math.Round(float64(g.width) * scale))
+	ph := int(math.Round(float64(g.height) * scale))
+	rect := image.Rect(0, 0, pw, ph).Add(image.Pt((w-pw)/2, (h-ph)/2))
+	if scale == g.viewScale && rect == g.viewRect {
+		return
+	}
+	g.viewScale, g.viewRect = scale, rect
+	g.view.Reset()
+	g.view.Scale(scale, scale)
+	g.view.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+	g.idleDrawn = false // a resized screen must be repainted
+}
@@ -0,0 +1,184 @@
+package demo
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestEaseSpeed(t *testing.T) {
+	tests := []struct {
+		from, to float64
+	}{
+		{1, 2},
+		{1, 0.5},
+		{0.5, 2},
+		{1.3, 1.3},
+	}
+	for _, tt := range tests {
+		// Eased at 60 TPS, the speed moves toward the target without
+		// overshooting, is 90% there after a quarter second and on it soon
+		// after
+		speed := tt.from
+		for tick := 1; tick <= 120; tick++ {
+			next := easeSpeed(speed, tt.to, 1)
+			if math.Abs(tt.to-next) > math.Abs(tt.to-speed) || (next-tt.to)*(tt.from-tt.to) < 0 {
+				t.Fatalf("%v to %v: tick %d went from %v to %v", tt.from, tt.to, tick, speed, next)
+			}
+			speed = next
+			if tick == 15 && math.Abs(tt.to-speed) > 0.1*math.Abs(tt.to-tt.from) {
+				t.Errorf("%v to %v: %v after 15 ticks, want 90%% of the way", tt.from, tt.to, speed)
+			}
+		}
+		if speed != tt.to {
+			t.Errorf("%v to %v: %v after 2 seconds, want the target exactly", tt.from, tt.to, speed)
+		}
+	}
+}
+
+func TestEaseSpeedTickRate(t *testing.T) {
+	// Four 240 TPS ticks cover what one 60 TPS tick does
+	fast := 1.0
+	for range 4 {
+		fast = easeSpeed(fast, 2, 0.25)
+	}
+	if slow := easeSpeed(1, 2, 1); math.Abs(fast-slow) > 1e-9 {
+		t.Errorf("eased to %v at 240 TPS, %v at 60 TPS", fast, slow)
+	}
+}
+
+// TestSetBounds lays the demo out at other resolutions, checking every
+// canvas is sized from the logical resolution, not the 800x600 default
+func TestSetBounds(t *testing.T) {
+	g := testGame(t)
+	t.Cleanup(func() { g.SetBounds(ScreenWidth, ScreenHeight) })
+
+	for _, size := range [][2]int{{1280, 720}, {640, 480}, {2560, 1080}, {ScreenWidth, ScreenHeight}} {
+		w, h := size[0], size[1]
+		g.SetBounds(w, h)
+		if g.width != w || g.height != h {
+			t.Errorf("SetBounds(%d, %d) left the demo at %dx%d", w, h, g.width, g.height)
+		}
+		for _, c := range []struct {
+			name string
+			img  *ebiten.Image
+			w, h int
+		}{
+			{"frame", g.frame, w, h},
+			{"intro canvas", g.introCanvas, w, h},
+			{"main canvas", g.mainCanvas, w, h},
+			{"hue canvas", g.hueCanvas, w, h},
+			{"intro ring", g.introRing, w + introRingMargin, fontHeight * 2},
+			{"intro strip", g.introStrip, w, fontHeight * 2},
+			{"title canvas", g.titleCanvas, w, g.bannerHeight},
+			{"scroll surface", g.scroller.surf, 2 * w, fontHeight * 3},
+		} {
+			if c.img == nil {
+				t.Errorf("%dx%d: %s is nil", w, h, c.name)
+				continue
+			}
+			if b := c.img.Bounds(); b.Dx() != c.w || b.Dy() != c.h {
+				t.Errorf("%dx%d: %s is %dx%d, want %dx%d", w, h, c.name, b.Dx(), b.Dy(), c.w, c.h)
+			}
+		}
+		if g.scroller.width != w {
+			t.Errorf("%dx%d: scroller %d wide", w, h, g.scroller.width)
+		}
+
+		// A frame at the new size draws without trouble
+		g.Update()
+		g.Draw(ebiten.NewImage(w, h))
+	}
+}
+
+// TestSetView fits the logical picture on device-sized screens: scaled to
+// fill the limiting axis and centered, on a whole scale factor only when
+// that still fills it
+func TestSetView(t *testing.T) {
+	tests := []struct {
+		name  string
+		w, h  int
+		scale float64
+		rect  image.Rectangle
+	}{
+		{"logical size", 800, 600, 1, image.Rect(0, 0, 800, 600)},
+		{"2x", 1600, 1200, 2, image.Rect(0, 0, 1600, 1200)},
+		{"1.25x", 1000, 750, 1.25, image.Rect(0, 0, 1000, 750)},
+		{"1.5x", 1200, 900, 1.5, image.Rect(0, 0, 1200, 900)},
+		{"2.5x", 2000, 1500, 2.5, image.Rect(0, 0, 2000, 1500)},
+		{"full HD", 1920, 1080, 1.8, image.Rect(240, 0, 1680, 1080)},
+		{"16:9 at 2x", 3840, 2160, 3.6, image.Rect(480, 0, 3360, 2160)},
+		{"a pixel over 2x", 1601, 1201, 2, image.Rect(0, 0, 1600, 1200)},
+		{"smaller", 400, 400, 0.5, image.Rect(0, 50, 400, 350)},
+	}
+	for _, tt := range tests {
+		g := &Game{width: ScreenWidth, height: ScreenHeight}
+		g.setView(tt.w, tt.h)
+		if g.viewScale != tt.scale || g.viewRect != tt.rect {
+			t.Errorf("%s: %dx%d gets scale %v at %v, want %v at %v", tt.name, tt.w, tt.h, g.viewScale, g.viewRect, tt.scale, tt.rect)
+		}
+		// The view maps the logical corners onto the picture's
+		x0, y0 := g.view.Apply(0, 0)
+		x1, y1 := g.view.Apply(ScreenWidth, ScreenHeight)
+		if got := image.Rect(int(x0), int(y0), int(x1), int(y1)); got != tt.rect {
+			t.Errorf("%s: view maps the screen to %v, want %v", tt.name, got, tt.rect)
+		}
+	}
+}
+
+// TestScanlineCount checks the CRT scanlines are one device pixel apart,
+// one dark and one light row per pair, whatever the screen scale
+func TestScanlineCount(t *testing.T) {
+	for _, size := range [][2]int{{800, 600}, {1600, 1200}, {1000, 750}, {3840, 2160}, {400, 400}} {
+		g := &Game{width: ScreenWidth, height: ScreenHeight}
+		g.setView(size[0], size[1])
+		if rows := float32(g.viewRect.Dy()); g.scanlineCount(g.height)*2 != rows {
+			t.Errorf("%dx%d: %v scanline pairs over %v device rows", size[0], size[1], g.scanlineCount(g.height), rows)
+		}
+		strip := fontHeight * 2
+		if rows := float32(float64(strip) * g.viewScale); g.scanlineCount(strip)*2 != rows {
+			t.Errorf("%dx%d: %v scanline pairs over the %v rows of the intro strip", size[0], size[1], g.scanlineCount(strip), rows)
+		}
+		g.reducedMotion = true
+		if n := g.scanlineCount(g.height); n != 0 {
+			t.Errorf("%dx%d: %v scanline pairs in reduced motion mode, want none", size[0], size[1], n)
+		}
+	}
+}
+
+// TestDrawDeviceScreen draws onto device-sized screens, as LayoutF asks for
+// on a high-DPI display
+func TestDrawDeviceScreen(t *testing.T) {
+	g := testGame(t)
+	t.Cleanup(func() { g.setView(g.width, g.height) })
+
+	for _, size := range [][2]int{{1600, 1200}, {1000, 750}, {3840, 2160}} {
+		g.setView(size[0], size[1])
+		g.Update()
+		g.Draw(ebiten.NewImage(size[0], size[1]))
+	}
+}
+
+func TestDMASpacing(t *testing.T) {
+	tests := []struct {
+		name                  string
+		rows, cols            int
+		width, height, banner int
+		x, y                  float64
+	}{
+		// The original 4x4 grid keeps its 200x140 cells
+		{"default", 4, 4, ScreenWidth, ScreenHeight, defaultBannerHeight, 200, 140},
+		{"4x2", 2, 4, ScreenWidth, ScreenHeight, defaultBannerHeight, 200, 280},
+		{"single row", 1, 8, ScreenWidth, ScreenHeight, defaultBannerHeight, 100, 560},
+		{"twice the size", 4, 4, 2 * ScreenWidth, 2*(ScreenHeight-defaultBannerHeight) + defaultBannerHeight, defaultBannerHeight, 400, 280},
+		{"taller banner", 4, 4, ScreenWidth, ScreenHeight, 336, 200, 70},
+	}
+	for _, tt := range tests {
+		g := &Game{dmaRows: tt.rows, dmaCols: tt.cols, width: tt.width, height: tt.height, bannerHeight: tt.banner}
+		if x, y := g.dmaSpacing(); math.Abs(x-tt.x) > 1e-9 || math.Abs(y-tt.y) > 1e-9 {
+			t.Errorf("%s: spacing = %v, %v, want %v, %v", tt.name, x, y, tt.x, tt.y)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package demo
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Cube rotation per pixel of mouse drag, in radians
+const grabRadiansPerPixel = 0.01
+
+// cursorPosition returns the mouse position in logical screen pixels. The
+// layout is in device pixels on a high-DPI display, see LayoutF.
+func (g *Game) cursorPosition() (x, y float64) {
+	cx, cy := ebiten.CursorPosition()
+	inv := g.view
+	inv.Invert()
+	return inv.Apply(float64(cx), float64(cy))
+}
+
+// cubeAt returns the index of the topmost cube whose projected bounding box
+// contains x, y, or -1
+func (g *Game) cubeAt(x, y float64) int {
+	// Later cubes are drawn over earlier ones
+	for i := len(g.cubes) - 1; i >= 0; i-- {
+		cx, cy := g.cubePosition(i)
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, v := range g.cubes[i].ProjectedVertices(cx, cy) {
+			minX, maxX = math.Min(minX, v[0]), math.Max(maxX, v[0])
+			minY, maxY = math.Min(minY, v[1]), math.Max(maxY, v[1])
+		}
+		if x >= minX && x <= maxX && y >= minY && y <= maxY {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateGrab lets the left mouse button grab a cube and turn it by dragging.
+// The grabbed cube holds its place and stops its own spin until released.
+func (g *Game) updateGrab() {
+	x, y := g.canvasCursor()
+
+	if g.grabbed >= 0 && !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.grabbed = -1
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		g.grabbed = g.cubeAt(x, y)
+	}
+	if g.grabbed >= 0 {
+		// Dragging down tips the near face down, dragging right turns it right
+		dx, dy := x-g.grabX, y-g.grabY
+		g.cubes[g.grabbed].Rotate(dy*grabRadiansPerPixel, -dx*grabRadiansPerPixel, 0)
+	}
+	g.grabX, g.grabY = x, y
+}
@@ -0,0 +1,94 @@
+package demo
+
+import (
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Hue rotation shader, applied to the demo frame before the CRT look
+const hueShaderSrc = `
+package main
+
+// HueShift is the hue rotation in radians
+var HueShift float
+
+func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+	var col vec4
+	col = imageSrc0At(texCoord)
+
+	// Rotate the color around the gray axis. The colors are premultiplied,
+	// which the rotation keeps: it is linear and leaves gray alone.
+	var k vec3
+	k = vec3(0.57735026)
+	var c float
+	var s float
+	c = cos(HueShift)
+	s = sin(HueShift)
+	col.rgb = col.rgb*c + cross(k, col.rgb)*s + k*dot(k, col.rgb)*(1.0-c)
+	col.rgb = clamp(col.rgb, 0.0, col.a)
+
+	return col * color
+}
+`
+
+const (
+	// Hue cycle speed, in radians per 60 TPS tick: a full turn in 20 s
+	hueCycleSpeed = 2 * math.Pi / (20 * baseTPS)
+
+	// Hue step of the step key, a twelfth of the color wheel
+	hueStep = math.Pi / 6
+)
+
+// SetHueShift rotates the hue of the whole demo picture by radians, 0 for
+// the normal colors
+func (g *Game) SetHueShift(radians float64) {
+	g.hueShift = math.Mod(radians, 2*math.Pi)
+	if g.hueShift < 0 {
+		g.hueShift += 2 * math.Pi
+	}
+	// A full turn of steps lands on 0 despite rounding
+	if math.Abs(g.hueShift) < 1e-9 || math.Abs(g.hueShift-2*math.Pi) < 1e-9 {
+		g.hueShift = 0
+	}
+}
+
+// SetHueCycle slowly turns the hue around the color wheel while on. Turned
+// off, the hue stays where it got to.
+func (g *Game) SetHueCycle(on bool) {
+	g.hueCycle = on
+}
+
+// updateHue moves the hue cycle on by a tick
+func (g *Game) updateHue() {
+	if g.hueCycle {
+		g.SetHueShift(g.hueShift + hueCycleSpeed*g.step)
+	}
+}
+
+// hueFrame returns the demo frame to show: src itself with the normal
+// colors, or src through the hue shader into hueCanvas
+func (g *Game) hueFrame(src *ebiten.Image) *ebiten.Image {
+	if g.hueShift == 0 || g.hueShader == nil {
+		return src
+	}
+	g.hueCanvas.Clear()
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = src
+	op.Uniforms = map[string]any{"HueShift": float32(g.hueShift)}
+	g.hueCanvas.DrawRectShader(g.width, g.height, g.hueShader, op)
+	return g.hueCanvas
+}
+
+// compileHueShader builds the hue shader, leaving the colors alone if it
+// fails
+func (g *Game) compileHueShader() {
+	var err error
+	if g.hueShader, err = ebiten.NewShader([]byte(hueShaderSrc)); err != nil {
+		log.Printf("Failed to compile hue shader: %v", err)
+		if line := shaderErrorSource(hueShaderSrc, err); line != "" {
+			log.Printf("Hue shader error at %s", line)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package demo
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// KeyBindings maps each keyboard action to a key
+type KeyBindings struct {
+	VolumeUp    ebiten.Key
+	VolumeDown  ebiten.Key
+	SpeedUp     ebiten.Key
+	SpeedDown   ebiten.Key
+	Freeze      ebiten.Key // Freeze/unfreeze all animation
+	FreezeAudio ebiten.Key // Toggle whether freezing also pauses the music
+	Mute        ebiten.Key // Silence the music, keeping the volume
+	SwitchTrack ebiten.Key // Crossfade to the other tune, if there are two
+	SeekBack    ebiten.Key // Scrub the music back
+	SeekForward ebiten.Key // Scrub the music forward
+	Reverse     ebiten.Key // Held: play the music backward
+	Visualizer  ebiten.Key // Toggle the music level bars
+	Debug       ebiten.Key // Toggle the grid and position overlay
+	Fullscreen  ebiten.Key
+	Reset       ebiten.Key // Restart from the intro
+	CubeAA      ebiten.Key // Toggle antialiased cube faces
+	FOVUp       ebiten.Key // Held: flatter, more telephoto cubes
+	FOVDown     ebiten.Key // Held: wider, stronger perspective
+	Wireframe   ebiten.Key // Toggle edges-only cubes
+	Glass       ebiten.Key // Toggle translucent cube faces
+	CRT         ebiten.Key // Toggle the CRT look on the demo
+
+	ReducedMotion   ebiten.Key // Toggle the accessibility mode
+	ScrollDirection ebiten.Key // Flip the megatwist scroller direction
+	CameraReset     ebiten.Key // Undo the free camera zoom and pan
+	VignetteUp      ebiten.Key // Darken the CRT corners more
+	VignetteDown    ebiten.Key // Darken the CRT corners less
+	Starfield       ebiten.Key // Toggle the stars behind the rotozoom
+	BeatFormations  ebiten.Key // Toggle cube formations changing on the beat
+	HueCycle        ebiten.Key // Toggle the slow hue rotation of the picture
+	HueStep         ebiten.Key // Turn the hue of the picture a step further
+	RotozoomFreeze  ebiten.Key // Hold the rotozoom still, the rest moving on
+
+	// Toggle each effect, in LayerNames order
+	Layers [5]ebiten.Key
+}
+
+// DefaultKeyBindings returns the stock keyboard layout
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		VolumeUp:    ebiten.KeyUp,
+		VolumeDown:  ebiten.KeyDown,
+		SpeedUp:     ebiten.KeyEqual,
+		SpeedDown:   ebiten.KeyMinus,
+		Freeze:      ebiten.KeyP,
+		FreezeAudio: ebiten.KeyO,
+		Mute:        ebiten.KeyM,
+		SwitchTrack: ebiten.KeyT,
+		SeekBack:    ebiten.KeyLeft,
+		SeekForward: ebiten.KeyRight,
+		Reverse:     ebiten.KeyBackspace,
+		Visualizer:  ebiten.KeyF5,
+		Debug:       ebiten.KeyF4,
+		Fullscreen:  ebiten.KeyF11,
+		Reset:       ebiten.KeyR,
+		CubeAA:      ebiten.KeyA,
+		FOVUp:       ebiten.KeyPageUp,
+		FOVDown:     ebiten.KeyPageDown,
+		Wireframe:   ebiten.KeyW,
+		Glass:       ebiten.KeyG,
+		CRT:         ebiten.KeyC,
+
+		ReducedMotion:   ebiten.KeyF2,
+		ScrollDirection: ebiten.KeyD,
+		CameraReset:     ebiten.KeyHome,
+		VignetteUp:      ebiten.KeyBracketRight,
+		VignetteDown:    ebiten.KeyBracketLeft,
+		Starfield:       ebiten.KeyS,
+		BeatFormations:  ebiten.KeyB,
+		HueCycle:        ebiten.KeyH,
+		HueStep:         ebiten.KeyJ,
+		RotozoomFreeze:  ebiten.KeyF,
+
+		Layers: [5]ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5},
+	}
+}
+
+// SetKeyBindings replaces the keyboard layout
+func (g *Game) SetKeyBindings(kb KeyBindings) {
+	g.keys = kb
+}
+
+// updateGamepad picks the first connected gamepad. When none is present the
+// gamepad helpers report false and the keyboard is the only input.
+func (g *Game) updateGamepad() {
+	g.gamepadIDs = ebiten.AppendGamepadIDs(g.gamepadIDs[:0])
+	g.hasGamepad = false
+	for _, id := range g.gamepadIDs {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			g.gamepadID = id
+			g.hasGamepad = true
+			return
+		}
+	}
+}
+
+func (g *Game) gamepadPressed(button ebiten.StandardGamepadButton) bool {
+	return g.hasGamepad && ebiten.IsStandardGamepadButtonPressed(g.gamepadID, button)
+}
+
+func (g *Game) gamepadJustPressed(button ebiten.StandardGamepadButton) bool {
+	return g.hasGamepad && inpututil.IsStandardGamepadButtonJustPressed(g.gamepadID, button)
+}
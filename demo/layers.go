@@ -0,0 +1,114 @@
+package demo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// LayerNames are the demo effects in their default back-to-front order. The
+// layer keys toggle them in this order, whatever order they are drawn in.
+var LayerNames = []string{"rotozoom", "scroller", "logos", "cubes", "banner"}
+
+// layer is one effect drawn by drawDemo, and whether it is shown
+type layer struct {
+	name string
+	draw func(dst *ebiten.Image)
+	on   bool
+}
+
+// defaultLayers returns the effects in LayerNames order, all shown. The
+// effects must exist already.
+func (g *Game) defaultLayers() []layer {
+	draws := map[string]func(dst *ebiten.Image){
+		"rotozoom": g.roto.Draw,
+		"scroller": g.drawScrollText,
+		"logos":    g.drawDMALogos,
+		"cubes":    g.draw3DCubes,
+		"banner":   g.drawTitleWithCopperbars,
+	}
+	layers := make([]layer, len(LayerNames))
+	for i, name := range LayerNames {
+		layers[i] = layer{name: name, draw: draws[name], on: true}
+	}
+	return layers
+}
+
+// SetLayers draws the named effects back to front in the given order and
+// hides the others, so one effect can be looked at alone or the scene
+// rearranged. Unknown or repeated names are an error and change nothing.
+func (g *Game) SetLayers(names []string) error {
+	layers := make([]layer, 0, len(g.layers))
+	for _, name := range names {
+		i := g.layerIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unknown layer %q, want %s", name, strings.Join(LayerNames, ", "))
+		}
+		if containsLayer(layers, name) {
+			return fmt.Errorf("layer %q given twice", name)
+		}
+		l := g.layers[i]
+		l.on = true
+		layers = append(layers, l)
+	}
+	// The others follow, hidden, ready to be toggled on
+	for _, l := range g.layers {
+		if !containsLayer(layers, l.name) {
+			l.on = false
+			layers = append(layers, l)
+		}
+	}
+	g.layers = layers
+	return nil
+}
+
+// SetLayerEnabled shows or hides one effect, keeping the order
+func (g *Game) SetLayerEnabled(name string, on bool) {
+	if i := g.layerIndex(name); i >= 0 {
+		g.layers[i].on = on
+	}
+}
+
+// layerIndex returns where the named effect is in the draw order, -1 if
+// there is no such effect
+func (g *Game) layerIndex(name string) int {
+	for i, l := range g.layers {
+		if l.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// containsLayer reports whether the named effect is in layers
+func containsLayer(layers []layer, name string) bool {
+	for _, l := range layers {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateLayerKeys toggles the effects with the number keys, one key per
+// entry of LayerNames
+func (g *Game) updateLayerKeys() {
+	for i, key := range g.keys.Layers {
+		if i < len(LayerNames) && inpututil.IsKeyJustPressed(key) {
+			if j := g.layerIndex(LayerNames[i]); j >= 0 {
+				g.layers[j].on = !g.layers[j].on
+			}
+		}
+	}
+}
+
+// drawLayers draws the shown effects back to front
+func (g *Game) drawLayers(dst *ebiten.Image) {
+	for _, l := range g.layers {
+		if l.on {
+			l.draw(dst)
+		}
+	}
+}
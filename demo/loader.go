@@ -0,0 +1,90 @@
+package demo
+
+import (
+	"image/color"
+	"math"
+	"sync/atomic"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Loading screen progress bar
+const (
+	loaderBarWidth  = 400
+	loaderBarHeight = 12
+)
+
+// Loader is an ebiten.Game that builds the demo on a background goroutine
+// and shows a progress bar meanwhile, so the window opens at once. The Game
+// only reaches the game loop once it is fully built, so the loop never sees
+// half-initialized fields.
+type Loader struct {
+	progress atomic.Uint64 // math.Float64bits of 0..1
+	done     chan *Game
+	game     *Game
+}
+
+// NewLoader starts building the demo from assets. setup, if not nil, runs
+// on the finished Game before the loop first touches it, e.g. to apply
+// settings.
+func NewLoader(assets Assets, setup func(*Game)) *Loader {
+	l := &Loader{done: make(chan *Game, 1)}
+	go func() {
+		g := newGame(assets, func(p float64) {
+			l.progress.Store(math.Float64bits(p))
+		})
+		if setup != nil {
+			setup(g)
+		}
+		l.done <- g
+	}()
+	return l
+}
+
+// Game returns the demo once it has taken over the loop, nil while loading.
+// Call it from the game loop or after ebiten.RunGame returns.
+func (l *Loader) Game() *Game {
+	return l.game
+}
+
+func (l *Loader) Update() error {
+	if l.game == nil {
+		select {
+		case l.game = <-l.done:
+		default:
+			return nil
+		}
+	}
+	return l.game.Update()
+}
+
+func (l *Loader) Draw(screen *ebiten.Image) {
+	if l.game != nil {
+		l.game.Draw(screen)
+		return
+	}
+
+	screen.Fill(color.Black)
+	p := math.Float64frombits(l.progress.Load())
+	x := float32(screen.Bounds().Dx()-loaderBarWidth) / 2
+	y := float32(screen.Bounds().Dy()-loaderBarHeight) / 2
+	vector.StrokeRect(screen, x-3, y-3, loaderBarWidth+6, loaderBarHeight+6, 1, color.White, false)
+	vector.DrawFilledRect(screen, x, y, float32(p*loaderBarWidth), loaderBarHeight, color.White, false)
+}
+
+func (l *Loader) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if l.game != nil {
+		return l.game.Layout(outsideWidth, outsideHeight)
+	}
+	return screenWidth, screenHeight
+}
+
+// LayoutF hands over to the game's high-DPI layout once it is built; the
+// loading bar is drawn at the logical size
+func (l *Loader) LayoutF(outsideWidth, outsideHeight float64) (float64, float64) {
+	if l.game != nil {
+		return l.game.LayoutF(outsideWidth, outsideHeight)
+	}
+	return screenWidth, screenHeight
+}
@@ -0,0 +1,124 @@
+package demo
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	_ "image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The tests that draw with Ebiten need its game loop, and so a display
+// (xvfb-run on a CI box without one). They only run with -gpu; without it
+// they are skipped and everything else runs headless.
+var gpu = flag.Bool("gpu", false, "run the tests and benchmarks that draw with Ebiten, inside its game loop")
+
+// The golden file tests compare against testdata; -update rewrites it from
+// the current output instead, to be reviewed in the diff
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !*gpu {
+		os.Exit(m.Run())
+	}
+
+	// Ebiten reads pixels back only inside its loop, so the tests run from
+	// the first Update
+	l := &testLoop{m: m, code: 1}
+	if err := ebiten.RunGame(l); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(l.code)
+}
+
+// testLoop runs the tests in its first Update and stops the game
+type testLoop struct {
+	m    *testing.M
+	code int
+}
+
+func (l *testLoop) Update() error {
+	l.code = l.m.Run()
+	return ebiten.Termination
+}
+
+func (l *testLoop) Draw(*ebiten.Image) {}
+
+func (l *testLoop) Layout(int, int) (int, int) {
+	return 320, 240
+}
+
+// needGPU skips tb unless the tests run in the game loop
+func needGPU(tb testing.TB) {
+	tb.Helper()
+	if !*gpu {
+		tb.Skip("draws with Ebiten; run with -gpu")
+	}
+}
+
+// loadAsset reads a file the demo embeds
+func loadAsset(tb testing.TB, name string) []byte {
+	tb.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "assets", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+// loadAssetImage decodes a PNG the demo embeds into an Ebiten image
+func loadAssetImage(tb testing.TB, name string) *ebiten.Image {
+	tb.Helper()
+	img, _, err := image.Decode(bytes.NewReader(loadAsset(tb, name)))
+	if err != nil {
+		tb.Fatalf("decode %s: %v", name, err)
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// flushGPU waits for the draws queued on img, so a benchmark times them
+func flushGPU(img *ebiten.Image) {
+	_ = img.At(0, 0)
+}
+
+var (
+	sharedGameOnce sync.Once
+	sharedGame     *Game
+)
+
+// testGame returns a Game built from the embedded assets, shared by the
+// tests since Ebiten allows one audio context per process. Its settings
+// come from an empty config dir, so the user's own don't leak in; a test
+// that changes it must put it back.
+func testGame(tb testing.TB) *Game {
+	tb.Helper()
+	needGPU(tb)
+	sharedGameOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "cocoisthebest-test")
+		if err != nil {
+			tb.Fatal(err)
+		}
+		for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+			os.Setenv(env, dir)
+		}
+		sharedGame = NewGame(Assets{
+			Title:   loadAsset(tb, "dma-70.png"),
+			Bars:    loadAsset(tb, "bars.png"),
+			Coco:    loadAsset(tb, "coco.png"),
+			DMALogo: loadAsset(tb, "small-dma-jelly.png"),
+			Font:    loadAsset(tb, "font.png"),
+			Music:   loadAsset(tb, "mindbomb.ym"),
+		})
+	})
+	if sharedGame == nil {
+		tb.Fatal("building the test game failed")
+	}
+	return sharedGame
+}
@@ -0,0 +1,74 @@
+package demo
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// MusicEnd is what happens when the music reaches the end of the tune
+type MusicEnd int
+
+const (
+	// MusicEndLoop plays the tune forever, the default
+	MusicEndLoop MusicEnd = iota
+	// MusicEndStop plays it once and shows "MUSIC FINISHED" until the music
+	// is scrubbed back
+	MusicEndStop
+	// MusicEndRestart plays it once, then restarts the demo from the intro
+	MusicEndRestart
+)
+
+// Scale of the "MUSIC FINISHED" indicator, half the font size
+const musicEndScale = 0.5
+
+// ParseMusicEnd reads a MusicEnd from its flag name: loop, stop or restart
+func ParseMusicEnd(name string) (MusicEnd, error) {
+	switch name {
+	case "loop":
+		return MusicEndLoop, nil
+	case "stop":
+		return MusicEndStop, nil
+	case "restart":
+		return MusicEndRestart, nil
+	}
+	return MusicEndLoop, fmt.Errorf("unknown music end %q, want loop, stop or restart", name)
+}
+
+// SetMusicEnd sets what happens when the tune ends
+func (g *Game) SetMusicEnd(end MusicEnd) {
+	g.musicEnd = end
+	if g.music != nil {
+		g.music.SetLooping(end == MusicEndLoop)
+	}
+}
+
+// updateMusicEnd notices a tune that played to its end. The player has
+// drained and paused itself by then, so nothing keeps reading the finished
+// decoder.
+func (g *Game) updateMusicEnd() {
+	if g.music == nil || g.audioPlayer == nil {
+		return
+	}
+	ended := g.music.Ended() && !g.audioPlayer.IsPlaying()
+	if ended == g.musicFinished {
+		return
+	}
+	g.musicFinished = ended
+	g.idleDrawn = false
+	if ended && g.musicEnd == MusicEndRestart && g.state == "demo" {
+		g.Reset()
+	}
+}
+
+// drawMusicEnd shows that the tune has finished, centered next to the banner
+func (g *Game) drawMusicEnd(dst *ebiten.Image) {
+	if !g.musicFinished || g.musicEnd != MusicEndStop {
+		return
+	}
+	y := float64(g.bannerEdge()) + 8
+	if g.bannerBottom {
+		y = float64(g.bannerEdge()) - 8 - fontHeight*musicEndScale
+	}
+	g.drawCentered(dst, "MUSIC FINISHED", y, musicEndScale)
+}
@@ -0,0 +1,44 @@
+package demo
+
+import "testing"
+
+func TestParseMusicEnd(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    MusicEnd
+		wantErr bool
+	}{
+		{name: "loop", want: MusicEndLoop},
+		{name: "stop", want: MusicEndStop},
+		{name: "restart", want: MusicEndRestart},
+		// Errors fall back to the default
+		{name: "", want: MusicEndLoop, wantErr: true},
+		{name: "Stop", want: MusicEndLoop, wantErr: true},
+		{name: "once", want: MusicEndLoop, wantErr: true},
+		{name: " loop", want: MusicEndLoop, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMusicEnd(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMusicEnd(%q) = %v, %v, want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetMusicEndLooping(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(5000), true)
+	g := &Game{music: y}
+	for _, tt := range []struct {
+		end  MusicEnd
+		loop bool
+	}{
+		{MusicEndStop, false},
+		{MusicEndLoop, true},
+		{MusicEndRestart, false},
+	} {
+		g.SetMusicEnd(tt.end)
+		if y.IsLooping() != tt.loop {
+			t.Errorf("SetMusicEnd(%v): IsLooping() = %v, want %v", tt.end, y.IsLooping(), tt.loop)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package demo
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// toggleMute silences the music at once, remembering the volume so un-muting
+// brings it back. While muted the volume keys change the remembered volume.
+func (g *Game) toggleMute() {
+	if g.music == nil {
+		return
+	}
+	g.muted = !g.muted
+	if g.muted {
+		g.unmutedVolume = g.music.GetVolume()
+		g.music.SetVolume(0)
+	} else {
+		g.music.SetVolume(g.unmutedVolume)
+	}
+}
+
+// volume returns the volume the music plays at when not muted
+func (g *Game) volume() float64 {
+	if g.muted {
+		return g.unmutedVolume
+	}
+	return g.music.GetVolume()
+}
+
+// setVolume sets the music volume, clamped to 0..1, without un-muting
+func (g *Game) setVolume(vol float64) {
+	vol = max(0, min(vol, 1))
+	if g.muted {
+		g.unmutedVolume = vol
+		return
+	}
+	g.music.SetVolume(vol)
+}
+
+// drawMuteIcon draws a crossed-out speaker in the bottom right corner
+func (g *Game) drawMuteIcon(screen *ebiten.Image) {
+	x := float32(g.width - 44)
+	y := float32(g.height - 36)
+	clr := color.RGBA{0xff, 0xff, 0xff, 0xc0}
+
+	// Speaker body and cone
+	vector.DrawFilledRect(screen, x, y+8, 8, 12, clr, true)
+	var path vector.Path
+	path.MoveTo(x+8, y+8)
+	path.LineTo(x+18, y)
+	path.LineTo(x+18, y+28)
+	path.LineTo(x+8, y+20)
+	path.Close()
+	vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	for i := range vertices {
+		vertices[i].SrcX = 1
+		vertices[i].SrcY = 1
+		vertices[i].ColorR = 1
+		vertices[i].ColorG = 1
+		vertices[i].ColorB = 1
+		vertices[i].ColorA = float32(clr.A) / 0xff
+	}
+	op := &ebiten.DrawTrianglesOptions{}
+	op.AntiAlias = true
+	screen.DrawTriangles(vertices, indices, whiteSubImage, op)
+
+	// Cross
+	vector.StrokeLine(screen, x+22, y+8, x+34, y+20, 3, clr, true)
+	vector.StrokeLine(screen, x+34, y+8, x+22, y+20, 3, clr, true)
+}
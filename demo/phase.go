@@ -0,0 +1,40 @@
+package demo
+
+import "fmt"
+
+// Phases of the demo, as reported by Phase and taken by SetPhase
+const (
+	PhaseIntro = "intro"
+	PhaseDemo  = "demo"
+)
+
+// Phase returns the phase the demo is in: PhaseIntro while the opening
+// line scrolls by, then PhaseDemo. The demo has no end phase; when the music
+// stops at its end (MusicEndStop) it stays in PhaseDemo.
+func (g *Game) Phase() string {
+	return g.state
+}
+
+// SetPhase jumps to a phase the way the demo gets there by itself, for
+// scripted tours and screenshots:
+//   - PhaseIntro does what the restart key does: pauses and rewinds the
+//     music, unfreezes, and puts the intro, scroller, copper bars, rotozoom,
+//     cubes, logos and formations back to their start
+//   - PhaseDemo does what the end of the intro (or the skip key) does: marks
+//     the intro done, restarts the demo's tick count and starts the music if
+//     it is not playing. Effects carry on from where they are.
+//
+// Setting the phase the demo is already in changes nothing. Call it from the
+// game loop, or from setup in NewLoader.
+func (g *Game) SetPhase(name string) error {
+	switch name {
+	case g.state:
+	case PhaseIntro:
+		g.Reset()
+	case PhaseDemo:
+		g.startDemo()
+	default:
+		return fmt.Errorf("unknown phase %q, want %s or %s", name, PhaseIntro, PhaseDemo)
+	}
+	return nil
+}
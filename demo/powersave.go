@@ -0,0 +1,60 @@
+package demo
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Power save: while nothing animates the game ticks at idleTPS and stops
+// redrawing, and any input wakes it for idleGrace ticks before it may sleep
+// again, so keys pressed while frozen still respond at once
+const (
+	idleTPS   = 10
+	idleGrace = 2 * baseTPS
+)
+
+// SetPowerSave turns the power save mode on or off. When on, freezing the
+// animation (P) drops the tick rate to idleTPS and stops redrawing the
+// unchanged frame after a short grace period. The music keeps playing
+// normally: Ebiten streams it from its own audio goroutine, not from ticks.
+func (g *Game) SetPowerSave(on bool) {
+	g.powerSave = on
+	if !on {
+		g.setIdle(false)
+	}
+}
+
+// updatePowerSave decides, once per tick, whether the game can sleep
+func (g *Game) updatePowerSave() {
+	g.inputKeys = inpututil.AppendJustPressedKeys(g.inputKeys[:0])
+	_, wheel := ebiten.Wheel()
+	input := len(g.inputKeys) > 0 || wheel != 0 ||
+		inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) ||
+		inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) ||
+		g.camDragging
+	if input || g.state != g.idleState {
+		g.idleState = g.state
+		g.wakeTicks = idleGrace
+	} else if g.wakeTicks > 0 {
+		g.wakeTicks--
+	}
+
+	g.setIdle(g.powerSave && g.frozen && g.wakeTicks == 0)
+}
+
+// setIdle switches between the full and the idle tick rate. The screen is
+// kept between frames while idle so Draw can skip repainting it.
+func (g *Game) setIdle(idle bool) {
+	if idle == g.idle {
+		return
+	}
+	g.idle = idle
+	g.idleDrawn = false
+	if idle {
+		g.activeTPS = ebiten.TPS()
+		ebiten.SetTPS(idleTPS)
+	} else {
+		ebiten.SetTPS(g.activeTPS)
+	}
+	ebiten.SetScreenClearedEveryFrame(!idle)
+}
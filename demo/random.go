@@ -0,0 +1,27 @@
+package demo
+
+import "math/rand/v2"
+
+// Randomized effects draw from the Game's own source rather than the
+// package-level math/rand functions, so a seed reproduces them exactly, for
+// screenshots and benchmarks.
+
+// NewGameWithSeed builds the demo like NewGame, with its random effects
+// seeded by seed so every run with the same seed looks the same
+func NewGameWithSeed(assets Assets, seed int64) *Game {
+	g := NewGame(assets)
+	g.SetSeed(seed)
+	return g
+}
+
+// SetSeed reseeds the random effects and starts them over from the seed.
+// Call it before the game loop, or from setup in NewLoader.
+func (g *Game) SetSeed(seed int64) {
+	g.rng = newRand(seed)
+	g.starfield = NewStarfield(starCount, g.rng)
+}
+
+// newRand returns a source seeded by seed
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), 0))
+}
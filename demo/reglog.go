@@ -0,0 +1,37 @@
+package demo
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+// SetRegisterLog makes the game write the chip registers to w whenever they
+// change while the music plays, one line per change: the play position in
+// milliseconds followed by the 16 register values in hex. Pass nil to stop.
+// Only backends that expose their registers (YM) produce any output.
+func (g *Game) SetRegisterLog(w io.Writer) {
+	g.regLog = w
+	g.lastRegs = [16]byte{}
+}
+
+// logRegisters appends the current registers to the register log if they
+// changed since the last line
+func (g *Game) logRegisters() {
+	if g.regLog == nil || g.music == nil || g.audioPlayer == nil || !g.audioPlayer.IsPlaying() {
+		return
+	}
+	snap, ok := g.music.(registerSnapshotter)
+	if !ok {
+		return
+	}
+	regs := snap.RegisterSnapshot()
+	if regs == g.lastRegs {
+		return
+	}
+	g.lastRegs = regs
+	if _, err := fmt.Fprintf(g.regLog, "%d % x\n", g.music.PositionMs(), regs[:]); err != nil {
+		log.Printf("Failed to write register log: %v", err)
+		g.regLog = nil
+	}
+}
@@ -0,0 +1,135 @@
+package demo
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The fixed frame the render tests draw, and its golden image. It is made
+// by the software path; run with -update to rewrite it after an intended
+// change to the cube fill, and look at the PNG before committing it.
+const (
+	renderWidth  = 320
+	renderHeight = 240
+	renderGolden = "testdata/render_cubes.png"
+)
+
+// renderCubes draws the opaque faces of three cubes at set angles onto dst,
+// back to front like Cube3D.Draw, with drawPolygon. The edges are left out:
+// they are stroked with vector, which only draws on an *ebiten.Image.
+func renderCubes(dst draw.Image) {
+	cubes := []struct {
+		x, y, size float64
+		ax, ay, az float64
+		palette    []color.Color
+	}{
+		{80, 90, 70, 0.5, 0.7, 0.2, defaultCubePalette},
+		{230, 80, 90, 2.1, 0.3, 1.1, cubePalettes["cyan"]},
+		{160, 180, 60, 0.9, 2.6, 0.4, cubePalettes["rainbow"]},
+	}
+	for _, cube := range cubes {
+		c := NewCube3D(cube.size)
+		c.Rotate(cube.ax, cube.ay, cube.az)
+		rotated := c.rotatedVertices()
+		projected := c.project(rotated, cube.x, cube.y)
+		for _, fd := range c.sortFaces(rotated) {
+			points := make([]float64, 0, 8)
+			for _, vi := range cubeFaces[fd.index] {
+				points = append(points, projected[vi][0], projected[vi][1])
+			}
+			drawPolygon(dst, points, cube.palette[fd.index%len(cube.palette)])
+		}
+	}
+}
+
+// checkGolden compares got with the golden PNG, or rewrites it with -update.
+// On a mismatch the frame drawn is saved to a temporary directory.
+func checkGolden(t *testing.T, got *image.RGBA, name string) {
+	t.Helper()
+	if *update {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, got); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if img.Bounds() != got.Bounds() {
+		t.Fatalf("frame is %v, %s is %v", got.Bounds(), name, img.Bounds())
+	}
+	want := image.NewRGBA(img.Bounds())
+	draw.Draw(want, want.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	differ := 0
+	var first image.Point
+	for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+		for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+			if got.RGBAAt(x, y) != want.RGBAAt(x, y) {
+				if differ == 0 {
+					first = image.Pt(x, y)
+				}
+				differ++
+			}
+		}
+	}
+	if differ == 0 {
+		return
+	}
+	t.Errorf("%d pixels differ from %s, the first at %v: %v, want %v",
+		differ, name, first, got.RGBAAt(first.X, first.Y), want.RGBAAt(first.X, first.Y))
+
+	// Kept after the test, unlike t.TempDir, to be looked at
+	dir, err := os.MkdirTemp("", "render-golden")
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	out := filepath.Join(dir, filepath.Base(name))
+	if png.Encode(&buf, got) == nil && os.WriteFile(out, buf.Bytes(), 0o644) == nil {
+		t.Logf("frame drawn saved as %s", out)
+	}
+}
+
+// TestRenderGolden draws the fixed frame in software, headless
+func TestRenderGolden(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, renderWidth, renderHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(defaultDemoBackground), image.Point{}, draw.Src)
+	renderCubes(dst)
+	checkGolden(t, dst, renderGolden)
+}
+
+// TestRenderGoldenGPU draws the same frame to an offscreen *ebiten.Image and
+// reads it back: the GPU fill must give exactly the software pixels
+func TestRenderGoldenGPU(t *testing.T) {
+	needGPU(t)
+	if *update {
+		t.Skip("the golden image comes from the software path")
+	}
+	img := ebiten.NewImage(renderWidth, renderHeight)
+	img.Fill(defaultDemoBackground)
+	renderCubes(img)
+
+	dst := image.NewRGBA(img.Bounds())
+	img.ReadPixels(dst.Pix)
+	checkGolden(t, dst, renderGolden)
+}
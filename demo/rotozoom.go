@@ -0,0 +1,120 @@
+package demo
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// Virtual rotozoom canvas size. The tile repeats across it and it is
+	// rotated around its center; the texture itself is never allocated.
+	canvasWidth  = screenWidth * 8
+	canvasHeight = screenHeight * 8
+)
+
+// Rotozoom rotates, zooms and sways a repeating tile across the whole screen
+type Rotozoom struct {
+	tile *ebiten.Image
+
+	posXi float64
+	posZi float64
+	posRi float64
+
+	// Speed scales how far Update advances the phases; 1 is one 60 TPS tick
+	Speed float64
+
+	// Tint multiplies the tile colors; the default 50% gray darkens the
+	// background so the effects in front stand out
+	Tint color.RGBA
+
+	// Smooth samples the tile with linear filtering instead of nearest
+	// neighbor, so it stops shimmering when zoomed in. Zoomed out, Ebiten
+	// then also picks a downscaled mipmap of the tile, which keeps the
+	// repeats from aliasing. Off, the default, keeps the pixel look.
+	Smooth bool
+
+	// Opacity fades the whole layer, letting what is drawn before it show
+	// through; 1 covers it
+	Opacity float64
+
+	// One screen-sized quad sampling the tile with repeat addressing
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+// NewRotozoom creates a rotozoom repeating tile
+func NewRotozoom(tile *ebiten.Image) *Rotozoom {
+	return &Rotozoom{
+		tile:     tile,
+		Speed:    1,
+		Tint:     color.RGBA{0x80, 0x80, 0x80, 0xff},
+		Opacity:  1,
+		vertices: make([]ebiten.Vertex, 4),
+		indices:  []uint16{0, 1, 2, 1, 3, 2},
+	}
+}
+
+// Update advances the zoom, rotation and sway phases by one tick
+func (r *Rotozoom) Update() {
+	r.posXi += 0.008 * r.Speed
+	r.posZi += 0.003 * r.Speed
+	r.posRi += 0.005 * r.Speed
+}
+
+// Reset rewinds the zoom, rotation and sway to their starting point
+func (r *Rotozoom) Reset() {
+	r.posXi, r.posZi, r.posRi = 0, 0, 0
+}
+
+// Draw fills dst with the rotated and zoomed tile
+func (r *Rotozoom) Draw(dst *ebiten.Image) {
+	zoom := 0.5 + math.Abs(math.Sin(r.posZi)*2.5)
+	rot := 360.0 / 4.0 * math.Cos(r.posRi*4-math.Cos(r.posRi-0.01)) * 0.3 * math.Pi / 180
+
+	w := float64(dst.Bounds().Dx())
+	h := float64(dst.Bounds().Dy())
+
+	oscX := (w / 4) * math.Cos(r.posXi*4-math.Cos(r.posXi-0.1))
+	oscY := (h / 2.7) * -math.Sin(r.posXi*2.3-math.Cos(r.posXi-0.1))
+
+	centerX := w/2 + oscX
+	centerY := h/2 + oscY
+
+	if r.tile == nil {
+		return
+	}
+
+	// Same transform as drawing the tiled virtual canvas, inverted so each
+	// screen corner gets its canvas coordinate. Canvas coordinates equal
+	// tile coordinates modulo the tile size, which AddressRepeat handles.
+	var geo ebiten.GeoM
+	geo.Translate(-float64(canvasWidth)/2, -float64(canvasHeight)/2)
+	geo.Rotate(rot)
+	geo.Scale(zoom, zoom)
+	geo.Translate(centerX, centerY)
+	geo.Invert()
+
+	corners := [4][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}}
+	for i, c := range corners {
+		sx, sy := geo.Apply(c[0], c[1])
+		r.vertices[i] = ebiten.Vertex{
+			DstX:   float32(c[0]),
+			DstY:   float32(c[1]),
+			SrcX:   float32(sx),
+			SrcY:   float32(sy),
+			ColorR: float32(r.Tint.R) / 0xff,
+			ColorG: float32(r.Tint.G) / 0xff,
+			ColorB: float32(r.Tint.B) / 0xff,
+			ColorA: float32(float64(r.Tint.A) / 0xff * r.Opacity),
+		}
+	}
+
+	op := &ebiten.DrawTrianglesOptions{}
+	op.Address = ebiten.AddressRepeat
+	if r.Smooth {
+		op.Filter = ebiten.FilterLinear
+	}
+	dst.DrawTriangles(r.vertices, r.indices, r.tile, op)
+}
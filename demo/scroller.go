@@ -0,0 +1,640 @@
+package demo
+
+import (
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Wave types for distortion
+const (
+	cdZero = iota
+	cdSlowSin
+	cdMedSin
+	cdFastSin
+	cdSlowDist
+	cdMedDist
+	cdFastDist
+	cdSplitted
+)
+
+const (
+	// Opacity of the black drop shadow under the scroll text
+	scrollShadowAlpha = 0.75
+
+	// Default drop shadow offset, one font pixel right and down
+	scrollShadowOffset = 3
+)
+
+// Scroller is the megatwist scroller: 3x scaled bitmap text whose scanlines
+// are shifted horizontally along precalculated distortion curves
+type Scroller struct {
+	font  *Font
+	surf  *ebiten.Image
+	width int // visible width; surf is twice as wide
+
+	text     string
+	runes    []rune
+	commands []scrollCommand // inline commands, ordered by letter index
+
+	// Timing set by the inline commands
+	wave    float64 // wave position, advanced by 15*speed*dir per tick
+	speed   float64
+	dir     float64 // 1 scrolls forward, -1 backward, see SetDirection
+	pause   float64 // 60 TPS ticks left before the text moves again
+	nextCmd int     // first command not yet run this pass
+
+	// Extra gap between glyphs, in font pixels (before the 3x scale)
+	letterSpacing int
+
+	// Scale the glyphs with linear filtering instead of nearest neighbor
+	smooth bool
+
+	// Drop shadow drawn under the text, offset by shadowX, shadowY screen
+	// pixels, see SetShadow
+	shadow           bool
+	shadowX, shadowY int
+
+	// Blank space between the end of the text and its restart, in screen
+	// pixels; negative means one scroller width
+	wrapGap int
+
+	iteration     float64 // ticks at 60 TPS, counting fractions
+	rendered      int     // letter offset surf holds, -1 when stale
+	frontWavePos  int
+	letterNum     int // first visible letter, counting every pass
+	pass          int // furthest pass through the text, for the commands
+	letterDecal   int
+	curves        [][]int
+	frontMainWave []int
+	position      []int
+}
+
+// NewScroller creates a scroller looping text in font. The text can carry
+// inline timing commands, which are not drawn:
+//
+//	{pause:60}  hold the text still for 60 ticks at 60 TPS, one second
+//	{speed:2}   scroll twice as fast from here on (1 is normal)
+//
+// A command runs when its position reaches the left edge of the screen.
+// Anything else in braces is drawn as is.
+func NewScroller(font *Font, text string) *Scroller {
+	s := &Scroller{
+		font:     font,
+		surf:     ebiten.NewImage(screenWidth*2, int(fontHeight*3)),
+		width:    screenWidth,
+		text:     text,
+		wrapGap:  -1,
+		speed:    1,
+		dir:      1,
+		rendered: -1,
+	}
+
+	// Init wave curves for scrolling
+	s.curves = make([][]int, 8)
+	s.createCurves()
+	s.layoutText()
+	s.precalcMainWave()
+
+	return s
+}
+
+// layoutText turns the text into the runes and commands to scroll, padding
+// the end with spaces so a whole wrapGap of blank separates the last letter
+// from the first one on every loop, whatever the text ends with
+func (s *Scroller) layoutText() {
+	s.runes, s.commands = parseScrollText(s.font, s.text)
+
+	if space, ok := s.font.Letter(' '); ok && s.advance(space) > 0 && len(s.runes) > 0 {
+		adv := s.advance(space)
+		gap := s.wrapGap
+		if gap < 0 {
+			gap = s.width
+		}
+		blank := 0
+		for i := len(s.runes) - 1; i >= 0 && s.runes[i] == ' '; i-- {
+			blank += adv
+		}
+		for ; blank < gap; blank += adv {
+			s.runes = append(s.runes, ' ')
+		}
+	}
+
+	s.precalcPosition()
+	s.rendered = -1
+}
+
+// SetWrapGap sets the blank space between the end of the text and its
+// restart, in screen pixels. A negative gap means one scroller width, the
+// default.
+func (s *Scroller) SetWrapGap(px int) {
+	s.wrapGap = px
+	s.layoutText()
+}
+
+// fontRunes returns the runes of text the font can draw. Anything else
+// becomes a space, or is dropped if the font has no space either, so the
+// letter positions and the rendered glyphs walk the same sequence.
+func fontRunes(font *Font, text string) []rune {
+	_, hasSpace := font.Letter(' ')
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if _, ok := font.Letter(r); ok {
+			runes = append(runes, r)
+		} else if hasSpace {
+			runes = append(runes, ' ')
+		}
+	}
+	return runes
+}
+
+// scrollCommand is an inline timing command found in the scroll text
+type scrollCommand struct {
+	at    int // index of the letter it precedes
+	name  string
+	value float64
+}
+
+// parseScrollText splits text into the runes to draw and the inline
+// commands between them. Malformed or unknown commands stay in the text.
+func parseScrollText(font *Font, text string) ([]rune, []scrollCommand) {
+	var runes []rune
+	var commands []scrollCommand
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		name, arg, _ := strings.Cut(text[start+1:end], ":")
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil || value < 0 || (name != "pause" && name != "speed") {
+			// Not a command: keep the brace and look again after it
+			runes = append(runes, fontRunes(font, text[:start+1])...)
+			text = text[start+1:]
+			continue
+		}
+		runes = append(runes, fontRunes(font, text[:start])...)
+		commands = append(commands, scrollCommand{at: len(runes), name: name, value: value})
+		text = text[end+1:]
+	}
+	return append(runes, fontRunes(font, text)...), commands
+}
+
+// runCommands runs the commands the first visible letter has reached
+func (s *Scroller) runCommands() {
+	for s.nextCmd < len(s.commands) && s.commands[s.nextCmd].at <= s.passLetter() {
+		switch cmd := s.commands[s.nextCmd]; cmd.name {
+		case "pause":
+			s.pause = cmd.value
+		case "speed":
+			s.speed = cmd.value
+		}
+		s.nextCmd++
+	}
+}
+
+// Update advances the scroller by step 60 TPS ticks: the wave position and
+// the letter tracking that follows it, so the text moves at the same speed
+// whatever the tick rate. All scroller state moves here, once per tick, so
+// skipped or extra draws can't make the text drift from the music; Draw
+// only reads it.
+func (s *Scroller) Update(step float64) {
+	s.iteration += step
+
+	// Wave position with speed multiplier for amplitude, held by a pause
+	if s.pause > 0 {
+		s.pause -= step
+	} else {
+		s.wave += 10.0 * 1.5 * s.speed * s.dir * step
+	}
+	// Rewinding past the start carries on into the end of the text: the
+	// wave and letter tables continue below 0, wrapping modulo their length
+	s.frontWavePos = int(math.Floor(s.wave))
+
+	// Calculate horizontal offset
+	decalX := 999999999
+	for ligne := 0; ligne < fontHeight; ligne++ {
+		c := s.getWave(s.frontWavePos + ligne)
+		if c < decalX {
+			decalX = c
+		}
+	}
+
+	// First visible letter
+	s.letterNum = s.letterAt(decalX)
+	s.letterDecal = s.getPosition(s.letterNum)
+
+	// Before the start of the text, as it swings in or is rewound past it,
+	// the letters shown are the blank end of the pass before: the commands
+	// wait for the text to come round
+	if s.letterNum < 0 {
+		return
+	}
+
+	// Commands run again on each new pass through the text. The twist can
+	// swing the text back a few letters, over the end of the last pass or
+	// within this one, which must not count as a new pass.
+	pass := 0
+	if n := len(s.position); n > 0 {
+		pass = s.letterNum / n
+	}
+	if pass > s.pass {
+		s.pass = pass
+		s.nextCmd = 0
+	} else if s.nextCmd > 0 && s.passLetter()+len(s.runes)/2 < s.commands[s.nextCmd-1].at {
+		s.nextCmd = 0
+	}
+	s.runCommands()
+}
+
+// letterAt returns the letter under x on the scroll surface, counting on
+// through the passes of the looping text: letter i is getLetter(i) and
+// starts at getPosition(i). It is found directly rather than stepped to
+// from the last one, so any jump of x lands on the right letter.
+func (s *Scroller) letterAt(x int) int {
+	n := len(s.position)
+	if n == 0 {
+		return 0
+	}
+	total := s.position[n-1]
+	if total <= 0 {
+		return 0
+	}
+	pass, rem := x/total, x%total
+	if rem < 0 {
+		pass--
+		rem += total
+	}
+	// The first letter ending after rem is the one it falls in
+	return pass*n + sort.Search(n, func(i int) bool { return s.position[i] > rem })
+}
+
+// passLetter returns the first visible letter's index in the text, for the
+// commands
+func (s *Scroller) passLetter() int {
+	n := len(s.position)
+	if n == 0 {
+		return 0
+	}
+	return (s.letterNum%n + n) % n
+}
+
+// Reset rewinds the scroller to the start of its text
+func (s *Scroller) Reset() {
+	s.iteration = 0
+	s.wave = 0
+	s.speed = 1
+	s.pause = 0
+	s.nextCmd = 0
+	s.pass = 0
+	s.frontWavePos = 0
+	s.letterNum = 0
+	s.letterDecal = 0
+	s.rendered = -1
+}
+
+// createCurves builds the delta table of each wave type
+func (s *Scroller) createCurves() {
+	for funcType := cdZero; funcType <= cdSplitted; funcType++ {
+		s.curves[funcType] = GenerateCurve(funcType)
+	}
+}
+
+// GenerateCurve returns the distortion table of a scroller wave type, from
+// 0 (flat) through the slow, medium and fast sines (1-3) and distorted sines
+// (4-6) to the split wave (7). Each entry is the change in horizontal offset
+// from the previous scanline, and the offsets also drift by the type's
+// progress over the table. Unknown types give nil.
+func GenerateCurve(funcType int) []int {
+	if funcType < cdZero || funcType > cdSplitted {
+		return nil
+	}
+
+	var step, progress float64
+
+	switch funcType {
+	case cdZero:
+		step, progress = 2.25, 0
+	case cdSlowSin:
+		step, progress = 0.20, 140
+	case cdMedSin:
+		step, progress = 0.25, 175
+	case cdFastSin:
+		step, progress = 0.30, 210
+	case cdSlowDist:
+		step, progress = 0.12, 175
+	case cdMedDist:
+		step, progress = 0.16, 210
+	case cdFastDist:
+		step, progress = 0.20, 245
+	case cdSplitted:
+		step, progress = 0.18, 0
+	}
+
+	local := []float64{}
+	decal := 0.0
+	previous := 0
+	maxAngle := 360.0
+	if funcType == cdSplitted {
+		maxAngle = 720.0
+	}
+
+	for i := 0.0; i < maxAngle-step; i += step {
+		val := 0.0
+		rad := i * math.Pi / 180
+
+		switch funcType {
+		case cdZero:
+			val = 0
+		case cdSlowSin:
+			val = 100 * math.Sin(rad)
+		case cdMedSin:
+			val = 110 * math.Sin(rad)
+		case cdFastSin:
+			val = 120 * math.Sin(rad)
+		case cdSlowDist:
+			val = 100*math.Sin(rad) + 25.0*math.Sin(rad*10)
+		case cdMedDist:
+			val = 110*math.Sin(rad) + 27.5*math.Sin(rad*9)
+		case cdFastDist:
+			val = 120*math.Sin(rad) + 30.0*math.Sin(rad*8)
+		case cdSplitted:
+			dir := 1.0
+			if len(local)%2 == 1 {
+				dir = -1.0
+			}
+			amp := 12.0
+			if i < 160 {
+				amp *= i / 160
+			} else if (720 - 160) < i {
+				amp *= (720 - i) / 160
+			}
+			val = 90*math.Sin(rad) + dir*amp*math.Sin(rad*3)
+		}
+		local = append(local, val)
+	}
+
+	curve := make([]int, len(local))
+	for i := 0; i < len(local); i++ {
+		nitem := -int(math.Floor(local[i] - decal))
+		curve[i] = nitem - previous
+		previous = nitem
+		decal += progress / float64(len(local))
+	}
+	return curve
+}
+
+func (s *Scroller) precalcPosition() {
+	count := 0
+	s.position = []int{}
+
+	for _, r := range s.runes {
+		if letter, ok := s.font.Letter(r); ok {
+			count += s.advance(letter)
+			s.position = append(s.position, count)
+		}
+	}
+}
+
+// advance is how far a glyph moves the pen on the scroll surface. Both
+// precalcPosition and displayText use it so the letter tracking always
+// matches what is drawn.
+func (s *Scroller) advance(letter *Letter) int {
+	return int(float64(letter.width+s.letterSpacing) * 3.0)
+}
+
+// SetWidth sets the width of the area the scroller is drawn across
+func (s *Scroller) SetWidth(w int) {
+	if w < 1 || w == s.width {
+		return
+	}
+	s.width = w
+	s.surf.Deallocate()
+	s.surf = ebiten.NewImage(w*2, int(fontHeight*3))
+	s.layoutText()
+}
+
+// SetDirection makes the text scroll forward (dir >= 0, the default) or
+// backward. Scrolled back past its start, the text wraps round to its end
+// like it does going forward. The letter tracking follows the wave position
+// either way, so the direction can flip at any tick without the visible
+// letters jumping.
+func (s *Scroller) SetDirection(dir int) {
+	s.dir = 1
+	if dir < 0 {
+		s.dir = -1
+	}
+}
+
+// SetSmooth scales the glyphs with linear filtering when on, for softer
+// edges; off (the default) keeps the blocky pixel look
+func (s *Scroller) SetSmooth(on bool) {
+	s.smooth = on
+	s.rendered = -1
+}
+
+// SetShadow draws the text a second time under itself, dark and offset by
+// dx, dy screen pixels, to stand out from a busy background
+func (s *Scroller) SetShadow(on bool, dx, dy int) {
+	s.shadow = on
+	s.shadowX, s.shadowY = dx, dy
+}
+
+// SetLetterSpacing adds px font pixels between glyphs. Negative spacing
+// pulls them together, but only so far that every glyph still advances at
+// least one font pixel: the layout and letter tracking need the text to
+// move forward glyph by glyph.
+func (s *Scroller) SetLetterSpacing(px int) {
+	if n := s.font.narrowest(); n > 0 {
+		px = max(px, 1-n)
+	}
+	s.letterSpacing = px
+	s.layoutText()
+}
+
+func (s *Scroller) precalcMainWave() {
+	frontMainWaveTable := []int{
+		cdSlowSin, cdSlowSin, cdSlowDist, cdSlowSin,
+		cdSlowSin, cdMedSin, cdFastSin, cdMedSin,
+		cdSlowSin, cdMedDist, cdMedSin, cdSlowSin,
+		cdSplitted,
+	}
+
+	count := 0
+	s.frontMainWave = []int{}
+
+	for _, waveType := range frontMainWaveTable {
+		wave := s.curves[waveType]
+		for _, val := range wave {
+			count += val
+			s.frontMainWave = append(s.frontMainWave, count)
+		}
+	}
+}
+
+func (s *Scroller) getSum(arr []int, index, decal int) int {
+	n := len(arr)
+	if n == 0 {
+		return decal
+	}
+
+	// Floored division, so negative indices (a wave scrolled backward past
+	// its start) continue the table instead of indexing out of range
+	maxVal := arr[n-1]
+	f := index / n
+	m := index % n
+	if m < 0 {
+		f--
+		m += n
+	}
+	return decal + f*maxVal + arr[m]
+}
+
+func (s *Scroller) getWave(i int) int {
+	return s.getSum(s.frontMainWave, i, 0)
+}
+
+// getPosition returns where letter i starts on the scroll surface, the
+// positions continuing past the end of the text into the next pass
+func (s *Scroller) getPosition(i int) int {
+	return s.getSum(s.position, i-1, 0)
+}
+
+func (s *Scroller) getLetter(pos int) rune {
+	n := len(s.runes)
+	if n == 0 {
+		return ' '
+	}
+	return s.runes[(pos%n+n)%n]
+}
+
+// Draw renders the twisted text into lines rows of dst starting at row top,
+// as of the last Update
+func (s *Scroller) Draw(dst *ebiten.Image, top, lines int) {
+	// Render text to scroll surface
+	s.displayText(s.letterNum)
+
+	// One options value reused for every scanline blit
+	op := &ebiten.DrawImageOptions{}
+
+	if s.shadow {
+		op.ColorScale.Scale(0, 0, 0, scrollShadowAlpha)
+		s.drawLines(dst, top, lines, s.shadowX, s.shadowY, op)
+		op.ColorScale.Reset()
+	}
+	s.drawLines(dst, top, lines, 0, 0, op)
+}
+
+// drawLines blits the twisted scanlines of surf into lines rows of dst
+// starting at row top, moved dx, dy pixels. The rows moved past the bottom
+// are left out so the shadow stays in the scroller's area, and the moved
+// columns still come from the wrapped text rather than leaving a gap.
+func (s *Scroller) drawLines(dst *ebiten.Image, top, lines, dx, dy int, op *ebiten.DrawImageOptions) {
+	// Calculate bounce effect
+	bounce := int(math.Floor(18.0 * math.Abs(math.Sin(s.iteration*0.1))))
+
+	scrollWidth := s.surf.Bounds().Dx()
+	scaledFontHeight := int(fontHeight * 3.0)
+
+	// Render each line with distortion
+	for ligne := max(0, -dy); ligne < min(lines, lines-dy); ligne++ {
+		sourceFontLine := ligne / 3
+
+		frontWave := s.getWave(s.frontWavePos + sourceFontLine)
+		scrollXRaw := frontWave - s.letterDecal - dx
+		y := float64(top + ligne + dy)
+
+		scaledLine := ((sourceFontLine+bounce)%fontHeight)*3 + (ligne % 3)
+
+		if scaledLine >= scaledFontHeight {
+			scaledLine = scaledLine % scaledFontHeight
+		}
+
+		if scrollXRaw < 0 {
+			visibleWidth := s.width + scrollXRaw
+			if visibleWidth > 0 {
+				srcRect := image.Rect(0, scaledLine, minInt(visibleWidth, scrollWidth), scaledLine+1)
+				op.GeoM.Reset()
+				op.GeoM.Translate(float64(-scrollXRaw), y)
+				dst.DrawImage(s.surf.SubImage(srcRect).(*ebiten.Image), op)
+			}
+			continue
+		}
+
+		wrapSegments(scrollXRaw, s.width, scrollWidth, func(srcX, dstX, w int) {
+			srcRect := image.Rect(srcX, scaledLine, srcX+w, scaledLine+1)
+			op.GeoM.Reset()
+			op.GeoM.Translate(float64(dstX), y)
+			dst.DrawImage(s.surf.SubImage(srcRect).(*ebiten.Image), op)
+		})
+	}
+}
+
+// wrapSegments tiles a surface surfWidth wide across [0, width), the surface
+// column at screen x being (scrollX + x) mod surfWidth. It calls blit for
+// each run of columns to copy, left to right: one when the view fits before
+// the surface edge, two (or more for a surface narrower than the view) when
+// it wraps. The runs always add up to exactly width.
+func wrapSegments(scrollX, width, surfWidth int, blit func(srcX, dstX, w int)) {
+	if surfWidth <= 0 {
+		return
+	}
+	srcX := scrollX % surfWidth
+	if srcX < 0 {
+		srcX += surfWidth
+	}
+	for dstX := 0; dstX < width; {
+		w := minInt(surfWidth-srcX, width-dstX)
+		blit(srcX, dstX, w)
+		dstX += w
+		srcX = 0
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// displayText renders the text from letterOffset onto surf. The surface only
+// changes when the first visible letter does, which is every few ticks, so
+// it is kept until then.
+func (s *Scroller) displayText(letterOffset int) {
+	if letterOffset == s.rendered {
+		return
+	}
+	s.rendered = letterOffset
+	s.surf.Clear()
+
+	xPos := 0
+	i := 0
+	maxWidth := s.surf.Bounds().Dx() + 200*3
+
+	op := &ebiten.DrawImageOptions{}
+	if s.smooth {
+		op.Filter = ebiten.FilterLinear
+	}
+	for xPos < maxWidth {
+		char := s.getLetter(i + letterOffset)
+		if letter, ok := s.font.Letter(char); ok {
+			op.GeoM.Reset()
+			op.GeoM.Scale(3.0, 3.0)
+			op.GeoM.Translate(float64(xPos), 0)
+			s.surf.DrawImage(s.font.Glyph(letter), op)
+			xPos += s.advance(letter)
+		}
+		i++
+	}
+}
@@ -0,0 +1,353 @@
+package demo
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testFont maps the DMA font without its sheet, for the tests that only
+// measure and lay out text
+func testFont() *Font {
+	f := &Font{letters: make(map[rune]*Letter)}
+	for _, d := range fontMap {
+		f.letters[d.char] = &Letter{x: d.x, y: d.y, width: d.width}
+	}
+	return f
+}
+
+// newTestScroller lays out text like NewScroller, without the surfaces and
+// curves that need a GPU
+func newTestScroller(text string) *Scroller {
+	s := &Scroller{font: testFont(), width: screenWidth, text: text, wrapGap: -1, speed: 1, dir: 1}
+	s.curves = make([][]int, 8)
+	s.createCurves()
+	s.layoutText()
+	s.precalcMainWave()
+	return s
+}
+
+func TestScrollerUnmappedRunes(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // the runes scrolled, before the wrap gap padding
+	}{
+		{"HELLO", "HELLO"},
+		{"hello", "     "},
+		{"A@B#C", "A B C"},
+		{"CAFÉ * 100%", "CAF    100 "},
+		{"~", " "},
+	}
+	for _, tt := range tests {
+		s := newTestScroller(tt.text)
+		if got := string(s.runes[:len([]rune(tt.want))]); got != tt.want {
+			t.Errorf("%q scrolls as %q, want %q", tt.text, got, tt.want)
+		}
+
+		// One position per rune, each of them a glyph displayText draws
+		if len(s.position) != len(s.runes) {
+			t.Errorf("%q: %d positions for %d runes", tt.text, len(s.position), len(s.runes))
+		}
+		x := 0
+		for i, r := range s.runes {
+			letter, ok := s.font.Letter(r)
+			if !ok {
+				t.Fatalf("%q: rune %d %q has no glyph", tt.text, i, r)
+			}
+			x += s.advance(letter)
+			if s.position[i] != x {
+				t.Fatalf("%q: letter %d ends at %d, want %d", tt.text, i, s.position[i], x)
+			}
+		}
+	}
+}
+
+func TestFontRunesWithoutSpace(t *testing.T) {
+	f := testFont()
+	delete(f.letters, ' ')
+	if got, want := fontRunes(f, "A B?c"), []rune("AB?"); !slices.Equal(got, want) {
+		t.Errorf("fontRunes = %q, want %q", got, want)
+	}
+}
+
+func TestParseScrollText(t *testing.T) {
+	tests := []struct {
+		text     string
+		runes    string
+		commands []scrollCommand
+	}{
+		{"HELLO", "HELLO", nil},
+		{"AB{pause:60}CD", "ABCD", []scrollCommand{{2, "pause", 60}}},
+		{"{speed:2}AB{speed:0.5}", "AB", []scrollCommand{{0, "speed", 2}, {2, "speed", 0.5}}},
+		{"A{pause:1}{speed:3}B", "AB", []scrollCommand{{1, "pause", 1}, {1, "speed", 3}}},
+		// Commands count the runes the font draws, unmapped ones as spaces
+		{"a{pause:5}B", " B", []scrollCommand{{1, "pause", 5}}},
+		// Anything else in braces stays in the text; the font has neither
+		// braces nor lowercase, so those show as spaces
+		{"A{pause}B", "A       B", nil},
+		{"A{pause:x}B", "A      :  B", nil},
+		{"A{speed:-1}B", "A      :-1 B", nil},
+		{"A{jump:3}B", "A     :3 B", nil},
+		{"A{pause:1", "A      :1", nil},
+		{"A}B", "A B", nil},
+		{"{X}{pause:2}Y", " X Y", []scrollCommand{{3, "pause", 2}}},
+	}
+	for _, tt := range tests {
+		runes, commands := parseScrollText(testFont(), tt.text)
+		if string(runes) != tt.runes || !slices.Equal(commands, tt.commands) {
+			t.Errorf("parseScrollText(%q) = %q, %v, want %q, %v", tt.text, string(runes), commands, tt.runes, tt.commands)
+		}
+	}
+}
+
+// checkLetterAt checks that x falls within letter letterAt(x)
+func checkLetterAt(t *testing.T, s *Scroller, x int) {
+	t.Helper()
+	i := s.letterAt(x)
+	if start, end := s.getPosition(i), s.getPosition(i+1); x < start || x >= end {
+		t.Fatalf("letterAt(%d) = %d, which spans [%d, %d)", x, i, start, end)
+	}
+}
+
+func TestScrollerLetterAt(t *testing.T) {
+	s := newTestScroller("HI!")
+	n := len(s.position)
+	total := s.position[n-1]
+	tests := []struct {
+		x, want int
+	}{
+		{0, 0},
+		{s.position[0] - 1, 0},
+		{s.position[0], 1},
+		// The last letter of the text, then the first of the next pass
+		{total - 1, n - 1},
+		{total, n},
+		{total + s.position[0], n + 1},
+		{5*total + 1, 5 * n},
+		// Before the start, in the pass before the first
+		{-1, -1},
+		{-total, -n},
+		{-total - 1, -n - 1},
+	}
+	for _, tt := range tests {
+		if got := s.letterAt(tt.x); got != tt.want {
+			t.Errorf("letterAt(%d) = %d, want %d", tt.x, got, tt.want)
+		}
+		checkLetterAt(t, s, tt.x)
+	}
+	for x := -2 * total; x < 3*total; x++ {
+		checkLetterAt(t, s, x)
+	}
+}
+
+func TestScrollerLetterAtEmpty(t *testing.T) {
+	for _, text := range []string{"", "{pause:10}"} {
+		s := newTestScroller(text)
+		for _, x := range []int{-100, 0, 100} {
+			if got := s.letterAt(x); got != 0 {
+				t.Errorf("%q: letterAt(%d) = %d, want 0", text, x, got)
+			}
+		}
+		s.Update(1)
+	}
+}
+
+// TestScrollerTracking scrolls through two passes of the text and back,
+// checking the first visible letter follows the wave
+func TestScrollerTracking(t *testing.T) {
+	s := newTestScroller("SHORT")
+	n := len(s.position)
+	check := func() {
+		t.Helper()
+		if start := s.getPosition(s.letterNum); start != s.letterDecal {
+			t.Fatalf("letter %d starts at %d, tracked at %d", s.letterNum, start, s.letterDecal)
+		}
+	}
+
+	ticks := 0
+	for ; s.letterNum < 2*n; ticks++ {
+		if ticks == 100000 {
+			t.Fatalf("letter %d after %d ticks, never reached the third pass", s.letterNum, ticks)
+		}
+		s.Update(1)
+		check()
+	}
+	s.SetDirection(-1)
+	for range ticks {
+		s.Update(1)
+		check()
+	}
+	if s.letterNum >= n {
+		t.Errorf("letter %d after scrolling back, want the first pass", s.letterNum)
+	}
+}
+
+// TestWrapSegments scans every scroll offset, checking the runs cover each
+// screen column once, from the surface column the modulo tiling puts there
+func TestWrapSegments(t *testing.T) {
+	tests := []struct {
+		width, surfWidth int
+		maxRuns          int
+	}{
+		{screenWidth, 2 * screenWidth, 2},
+		{screenWidth, screenWidth + 96, 2},
+		{screenWidth, screenWidth, 2},
+		{screenWidth, 300, 4},
+		{1, 7, 1},
+		{7, 1, 7},
+	}
+	for _, tt := range tests {
+		for scrollX := -2 * tt.surfWidth; scrollX < 2*tt.surfWidth; scrollX++ {
+			x, runs := 0, 0
+			wrapSegments(scrollX, tt.width, tt.surfWidth, func(srcX, dstX, w int) {
+				runs++
+				if dstX != x || w <= 0 || srcX < 0 || srcX+w > tt.surfWidth {
+					t.Fatalf("width %d of %d, scroll %d: run %d copies [%d, %d) to %d after column %d",
+						tt.width, tt.surfWidth, scrollX, runs, srcX, srcX+w, dstX, x)
+				}
+				if want := ((scrollX+dstX)%tt.surfWidth + tt.surfWidth) % tt.surfWidth; srcX != want {
+					t.Fatalf("width %d of %d, scroll %d: column %d from %d, want %d",
+						tt.width, tt.surfWidth, scrollX, dstX, srcX, want)
+				}
+				x += w
+			})
+			if x != tt.width || runs > tt.maxRuns {
+				t.Fatalf("width %d of %d, scroll %d: %d runs cover %d columns, want at most %d covering %d",
+					tt.width, tt.surfWidth, scrollX, runs, x, tt.maxRuns, tt.width)
+			}
+		}
+	}
+
+	wrapSegments(0, screenWidth, 0, func(int, int, int) {
+		t.Error("blit called for an empty surface")
+	})
+}
+
+// TestScrollerTickRate runs the same second of scrolling at 60 and 120 TPS
+func TestScrollerTickRate(t *testing.T) {
+	at60 := newTestScroller("AB{pause:30}CD")
+	at120 := newTestScroller("AB{pause:30}CD")
+	for tick := range 600 {
+		at60.Update(1)
+		at120.Update(0.5)
+		at120.Update(0.5)
+		if at60.wave != at120.wave || at60.letterNum != at120.letterNum || at60.iteration != at120.iteration {
+			t.Fatalf("tick %d: wave %v, letter %d, iteration %v at 60 TPS; %v, %d, %v at 120 TPS", tick,
+				at60.wave, at60.letterNum, at60.iteration, at120.wave, at120.letterNum, at120.iteration)
+		}
+	}
+}
+
+func TestScrollerPause(t *testing.T) {
+	for _, step := range []float64{1, 0.5, 0.25} {
+		// The pause starts once the first letter reaches the left edge
+		s := newTestScroller("{pause:60}ABC")
+		for i := 0; s.pause <= 0; i++ {
+			if i > 10000 {
+				t.Fatalf("step %v: the pause never starts", step)
+			}
+			s.Update(step)
+		}
+		held := 0
+		for start := s.wave; s.wave == start; s.Update(step) {
+			held++
+			if held > 1000 {
+				t.Fatalf("step %v: the text never moves", step)
+			}
+		}
+		if ticks := float64(held) * step; ticks < 59 || ticks > 61 {
+			t.Errorf("step %v: held for %v ticks at 60 TPS, want 60", step, ticks)
+		}
+	}
+}
+
+// TestScrollerRewindWraps scrolls back from the start: the text carries on
+// into its end, the blank wrap gap and then its last letters, instead of
+// stopping
+func TestScrollerRewindWraps(t *testing.T) {
+	s := newTestScroller("AB{pause:5}CDEF")
+	n := len(s.position)
+	s.SetDirection(-1)
+	for tick := range 3000 {
+		s.Update(1)
+		if start := s.getPosition(s.letterNum); start != s.letterDecal {
+			t.Fatalf("tick %d: letter %d starts at %d, tracked at %d", tick, s.letterNum, start, s.letterDecal)
+		}
+		if s.pause > 0 {
+			t.Fatalf("tick %d: the pause ran while rewinding before the start, at letter %d", tick, s.letterNum)
+		}
+	}
+	if s.wave >= 0 || s.letterNum > -n {
+		t.Errorf("rewound to wave %v, letter %d, want more than a pass before the start", s.wave, s.letterNum)
+	}
+	if got := s.getLetter(-1); got != s.runes[n-1] {
+		t.Errorf("letter -1 = %q, want the last one %q", got, s.runes[n-1])
+	}
+}
+
+// TestGenerateCurveGolden checks every wave table against its golden file
+// in testdata, so a change to the curve math shows up as a table diff. Run
+// with -update to rewrite them after an intended change.
+func TestGenerateCurveGolden(t *testing.T) {
+	names := []string{"zero", "slow sine", "medium sine", "fast sine", "slow distorted sine", "medium distorted sine", "fast distorted sine", "split"}
+	for funcType := cdZero; funcType <= cdSplitted; funcType++ {
+		name := fmt.Sprintf("testdata/curve_%d.txt", funcType)
+		got := GenerateCurve(funcType)
+		if *update {
+			writeIntTable(t, name, fmt.Sprintf("GenerateCurve(%d), the %s wave: %d scanline offset deltas", funcType, names[funcType], len(got)), got)
+			continue
+		}
+		want := readIntTable(t, name)
+		if len(got) != len(want) {
+			t.Errorf("GenerateCurve(%d): %d entries, want %d", funcType, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("GenerateCurve(%d)[%d] = %d, want %d", funcType, i, got[i], want[i])
+				break
+			}
+		}
+	}
+
+	// Past the known types there is no table
+	for _, funcType := range []int{cdZero - 1, cdSplitted + 1} {
+		if got := GenerateCurve(funcType); got != nil {
+			t.Errorf("GenerateCurve(%d) = %d entries, want nil", funcType, len(got))
+		}
+	}
+}
+
+// TestScrollerLetterSpacing pulls the glyphs together: however negative the
+// spacing, the narrowest glyph still advances, so the letter positions keep
+// increasing for letterAt's search
+func TestScrollerLetterSpacing(t *testing.T) {
+	tests := []struct {
+		px, want int
+	}{
+		{0, 0},
+		{4, 4},
+		{-8, -8},
+		// '!' and 'I' are 16 font pixels wide
+		{-15, -15},
+		{-16, -15},
+		{-1000, -15},
+	}
+	for _, tt := range tests {
+		s := newTestScroller("HI! A I")
+		s.SetLetterSpacing(tt.px)
+		if s.letterSpacing != tt.want {
+			t.Errorf("SetLetterSpacing(%d) left %d, want %d", tt.px, s.letterSpacing, tt.want)
+		}
+		for i := 1; i < len(s.position); i++ {
+			if s.position[i] <= s.position[i-1] {
+				t.Fatalf("spacing %d: letter %d at %d, not after letter %d at %d", tt.px, i, s.position[i], i-1, s.position[i-1])
+			}
+		}
+		for x := range s.getPosition(len(s.position)) {
+			if n := s.letterAt(x); s.getPosition(n) > x || s.getPosition(n+1) <= x {
+				t.Fatalf("spacing %d: letterAt(%d) = %d, spanning [%d, %d)", tt.px, x, n, s.getPosition(n), s.getPosition(n+1))
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package demo
+
+import (
+	"image/color"
+	"log"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How far the seek keys move the music
+const seekStep = 5 * time.Second
+
+// How long the position bar stays up after a scrub. It is always shown
+// with the visualizer.
+const seekBarTicks = 3 * baseTPS
+
+// Height of the position bar, in pixels
+const seekBarHeight = 3
+
+// The block sweeping along the bar for a tune of unknown length: its width
+// as a fraction of the bar, and its speed in radians per tick
+const (
+	seekBarBlock = 0.2
+	seekBarSweep = 0.05
+)
+
+// seekMusic moves the music by delta. A looping tune wraps around; a tune
+// that plays once is clamped to its start and end, so scrubbing past the end
+// finishes it. A tune of unknown length is only clamped at its start.
+func (g *Game) seekMusic(delta time.Duration) {
+	if g.music == nil || g.audioPlayer == nil {
+		return
+	}
+	g.seekBarTicks = seekBarTicks
+	g.idleDrawn = false
+
+	length := g.music.Duration()
+	pos := g.musicPosition() + delta
+	if g.music.IsLooping() && length > 0 {
+		pos %= length
+		if pos < 0 {
+			pos += length
+		}
+	}
+	pos = max(0, pos)
+	if length > 0 {
+		pos = min(pos, length)
+	}
+	if err := g.audioPlayer.SetPosition(pos); err != nil {
+		log.Printf("Failed to seek music: %v", err)
+	}
+	// Scrubbing back into a finished tune plays it again
+	if g.musicFinished && (pos < length || length <= 0 && delta < 0) {
+		g.audioPlayer.Play()
+	}
+}
+
+// musicPosition returns how far into the current pass the music is heard
+func (g *Game) musicPosition() time.Duration {
+	pos := g.audioPlayer.Position()
+	if length := g.music.Duration(); g.music.IsLooping() && length > 0 {
+		pos %= length
+	}
+	return pos
+}
+
+// updateSeekBar counts the bar down, asking for one more draw when it goes
+// so power save does not leave it on screen
+func (g *Game) updateSeekBar() {
+	if g.seekBarTicks == 0 {
+		return
+	}
+	g.seekBarTicks--
+	if g.seekBarTicks == 0 {
+		g.idleDrawn = false
+	}
+}
+
+// drawSeekBar draws the music position along the inner edge of the banner. A
+// tune of unknown length has no position to show, so a block sweeps to and
+// fro along the bar instead.
+func (g *Game) drawSeekBar(dst *ebiten.Image) {
+	if g.music == nil || g.audioPlayer == nil || (g.seekBarTicks == 0 && !g.visualizer) {
+		return
+	}
+
+	y := float32(g.bannerEdge())
+	if !g.bannerBottom {
+		y -= seekBarHeight
+	}
+	width := float32(g.width)
+	fill := color.RGBA{255, 165, 50, 255}
+	vector.DrawFilledRect(dst, 0, y, width, seekBarHeight, color.RGBA{0x40, 0x40, 0x40, 0xc0}, false)
+
+	length := g.music.Duration()
+	if length <= 0 {
+		block := width * seekBarBlock
+		x := (width - block) * float32(0.5+0.5*math.Sin(float64(g.vbl)*seekBarSweep))
+		vector.DrawFilledRect(dst, x, y, block, seekBarHeight, fill, false)
+		return
+	}
+	progress := min(float64(g.musicPosition())/float64(length), 1)
+	vector.DrawFilledRect(dst, 0, y, width*float32(progress), seekBarHeight, fill, false)
+}
@@ -0,0 +1,138 @@
+package demo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Settings are the user adjustments kept from one run to the next: the
+// volume, speed and display toggles changed with the keys
+type Settings struct {
+	Volume          float64 `json:"volume"`
+	Speed           float64 `json:"speed"`
+	CRT             bool    `json:"crt"`
+	Vignette        float64 `json:"vignette"`
+	ReducedMotion   bool    `json:"reducedMotion"`
+	SafeFlash       bool    `json:"safeFlash"`
+	Wireframe       bool    `json:"wireframe"`
+	Glass           bool    `json:"glass"`
+	CubeAntialias   bool    `json:"cubeAntialias"`
+	Visualizer      bool    `json:"visualizer"`
+	FOV             float64 `json:"fov"`
+	ScrollDirection int     `json:"scrollDirection"`
+	Starfield       bool    `json:"starfield"`
+	BeatFormations  bool    `json:"beatFormations"`
+	HueShift        float64 `json:"hueShift"`
+	HueCycle        bool    `json:"hueCycle"`
+}
+
+// SettingsPath returns where the settings are kept, settings.json in the
+// cocoisthebest directory of the user config dir
+func SettingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cocoisthebest", "settings.json"), nil
+}
+
+// Settings returns the current settings. Without music the volume is the
+// one loaded, so a run with no audio does not lose it.
+func (g *Game) Settings() Settings {
+	s := g.settings
+	if g.music != nil {
+		s.Volume = g.volume()
+	}
+	s.Speed = g.speedMultiplier
+	s.CRT = g.crt
+	s.Vignette = g.vignette
+	s.ReducedMotion = g.reducedMotion
+	s.SafeFlash = g.safeFlash
+	s.Wireframe = g.wireframe
+	s.Glass = g.glass
+	s.CubeAntialias = len(g.cubes) > 0 && g.cubes[0].Antialias
+	s.Visualizer = g.visualizer
+	s.FOV = g.fov
+	s.ScrollDirection = g.scrollDir
+	s.Starfield = g.stars
+	s.BeatFormations = g.beatFormations
+	s.HueShift = g.hueShift
+	s.HueCycle = g.hueCycle
+	return s
+}
+
+// ApplySettings sets the volume, speed and toggles, clamping the values to
+// the ranges the keys allow
+func (g *Game) ApplySettings(s Settings) {
+	g.settings = s
+	if g.music != nil {
+		g.setVolume(s.Volume)
+	}
+	g.speedMultiplier = max(0.5, min(s.Speed, 2.0))
+	g.speed = g.speedMultiplier
+	g.crt = s.CRT
+	g.SetVignette(s.Vignette)
+	g.SetReducedMotion(s.ReducedMotion)
+	g.SetSafeFlash(s.SafeFlash)
+	g.wireframe = s.Wireframe
+	g.glass = s.Glass
+	for _, c := range g.cubes {
+		c.Antialias = s.CubeAntialias
+	}
+	g.visualizer = s.Visualizer
+	g.fov = max(minFOV, min(s.FOV, maxFOV))
+	g.SetScrollDirection(s.ScrollDirection)
+	g.SetStarfield(s.Starfield)
+	g.SetBeatFormations(s.BeatFormations)
+	g.SetHueShift(s.HueShift)
+	g.SetHueCycle(s.HueCycle)
+}
+
+// loadSettings applies the saved settings. A missing file keeps the
+// defaults; fields missing from the file keep theirs too.
+func (g *Game) loadSettings() {
+	path, err := SettingsPath()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read settings: %v", err)
+		return
+	}
+
+	s := g.Settings()
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Printf("Failed to parse settings %s, using defaults: %v", path, err)
+		return
+	}
+	g.ApplySettings(s)
+}
+
+// saveSettings writes the current settings for the next run
+func (g *Game) saveSettings() {
+	path, err := SettingsPath()
+	if err != nil {
+		log.Printf("Failed to save settings: %v", err)
+		return
+	}
+	data, err := json.MarshalIndent(g.Settings(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to save settings: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("Failed to save settings: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		log.Printf("Failed to save settings: %v", err)
+	}
+}
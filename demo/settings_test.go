@@ -0,0 +1,136 @@
+package demo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newSettingsGame returns a Game with just the parts the settings reach
+func newSettingsGame(t *testing.T) *Game {
+	t.Helper()
+	g := &Game{
+		copper:          &CopperBars{},
+		roto:            &Rotozoom{},
+		scroller:        newTestScroller(""),
+		music:           newTestPlayer(t, newFakeTune(50000), true),
+		speedMultiplier: 1,
+		fov:             defaultFOV,
+		scrollDir:       1,
+	}
+	for range nbCubes {
+		g.cubes = append(g.cubes, NewCube3D(cubeSize))
+	}
+	return g
+}
+
+// testSettings changes every setting from its default
+var testSettings = Settings{
+	Volume:          0.25,
+	Speed:           1.5,
+	CRT:             true,
+	Vignette:        1.5,
+	ReducedMotion:   true,
+	SafeFlash:       true,
+	Wireframe:       true,
+	Glass:           true,
+	CubeAntialias:   true,
+	Visualizer:      true,
+	FOV:             400,
+	ScrollDirection: -1,
+	Starfield:       true,
+	BeatFormations:  true,
+	HueShift:        1.5,
+	HueCycle:        true,
+}
+
+func TestSettingsJSON(t *testing.T) {
+	data, err := json.Marshal(testSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Settings
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != testSettings {
+		t.Errorf("round trip through %s = %+v, want %+v", data, got, testSettings)
+	}
+}
+
+func TestApplySettings(t *testing.T) {
+	g := newSettingsGame(t)
+	g.ApplySettings(testSettings)
+	if got := g.Settings(); got != testSettings {
+		t.Errorf("Settings() = %+v, want %+v", got, testSettings)
+	}
+
+	// Out of range values are clamped to what the keys allow
+	s := testSettings
+	s.Volume, s.Speed, s.Vignette, s.FOV = 3, 10, 9, 1
+	g.ApplySettings(s)
+	got := g.Settings()
+	if got.Volume != 1 || got.Speed != 2 || got.Vignette != maxVignette || got.FOV != minFOV {
+		t.Errorf("clamped settings = %+v", got)
+	}
+}
+
+// useConfigDir points the user config dir at a temporary directory
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	path, err := SettingsPath()
+	if err != nil {
+		t.Skip("no user config dir:", err)
+	}
+	return path
+}
+
+func TestSettingsFile(t *testing.T) {
+	path := useConfigDir(t)
+	defaults := newSettingsGame(t).Settings()
+
+	// A missing file keeps the defaults
+	g := newSettingsGame(t)
+	g.loadSettings()
+	if got := g.Settings(); got != defaults {
+		t.Errorf("settings without a file = %+v, want the defaults %+v", got, defaults)
+	}
+
+	g.ApplySettings(testSettings)
+	g.saveSettings()
+	g = newSettingsGame(t)
+	g.loadSettings()
+	if got := g.Settings(); got != testSettings {
+		t.Errorf("settings saved and loaded = %+v, want %+v", got, testSettings)
+	}
+
+	for _, tt := range []struct {
+		name, file string
+		want       func(s *Settings)
+	}{
+		{"malformed", `{"speed": 1.5,`, func(*Settings) {}},
+		{"wrong type", `{"speed": "fast"}`, func(*Settings) {}},
+		// Fields missing from the file keep their defaults
+		{"partial", `{"speed": 1.5, "crt": true}`, func(s *Settings) { s.Speed, s.CRT = 1.5, true }},
+	} {
+		if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		want := defaults
+		tt.want(&want)
+		g := newSettingsGame(t)
+		g.loadSettings()
+		if got := g.Settings(); got != want {
+			t.Errorf("%s file: settings = %+v, want %+v", tt.name, got, want)
+		}
+	}
+
+	if filepath.Base(path) != "settings.json" {
+		t.Errorf("SettingsPath() = %s, want a settings.json", path)
+	}
+}
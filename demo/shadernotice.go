@@ -0,0 +1,67 @@
+package demo
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// How long the notice stays up when the CRT shader failed to compile
+const shaderNoticeTicks = 5 * baseTPS
+
+// Scale of the notice text, half the font size
+const shaderNoticeScale = 0.5
+
+var shaderNoticeLines = []string{"NO SHADER SUPPORT", "CRT EFFECT OFF"}
+
+// Kage reports errors as "line:column: message"
+var shaderErrorPos = regexp.MustCompile(`(\d+):\d+: `)
+
+// shaderErrorSource returns the source line an error from ebiten.NewShader
+// points at, with its number, or "" if the error has no position
+func shaderErrorSource(src string, err error) string {
+	m := shaderErrorPos.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ""
+	}
+	line, _ := strconv.Atoi(m[1])
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return fmt.Sprintf("line %d: %s", line, strings.TrimSpace(lines[line-1]))
+}
+
+// updateShaderNotice counts the notice down. Its last tick asks for one
+// more draw, so power save does not keep it on screen.
+func (g *Game) updateShaderNotice() {
+	if g.shaderNotice == 0 {
+		return
+	}
+	g.shaderNotice--
+	if g.shaderNotice == 0 {
+		g.idleDrawn = false
+	}
+}
+
+// drawShaderNotice tells the user the CRT shader is missing, centered near
+// the top of dst on a dark band
+func (g *Game) drawShaderNotice(dst *ebiten.Image) {
+	if g.shaderNotice == 0 {
+		return
+	}
+
+	lineHeight := fontHeight * shaderNoticeScale
+	top := 12.0
+	vector.DrawFilledRect(dst, 0, float32(top-4), float32(g.width),
+		float32(lineHeight*float64(len(shaderNoticeLines))+8), color.RGBA{0, 0, 0, 0xc0}, false)
+
+	for i, text := range shaderNoticeLines {
+		g.drawCentered(dst, text, top+float64(i)*lineHeight, shaderNoticeScale)
+	}
+}
@@ -0,0 +1,101 @@
+package demo
+
+import (
+	"image/color"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// Stars in the demo's starfield
+	starCount = 200
+
+	// Depth a star travels per tick at speed 1, out of the field's 1
+	starSpeed = 0.006
+
+	// Closest a star gets before it is sent back to the far end
+	starNear = 0.05
+
+	// Depth of a star's streak behind it, so fast stars draw longer lines
+	starTrail = 0.03
+
+	// How opaque the rotozoom is drawn over the stars, which would
+	// otherwise be hidden by its tile
+	starfieldRotoOpacity = 0.6
+)
+
+// star is a point in the field: x and y in -1..1 across the screen at depth
+// 1, z from 1 (far) down to starNear (close)
+type star struct {
+	x, y, z float64
+}
+
+// Starfield flies stars toward the viewer, drawn as short streaks that
+// brighten as they come closer
+type Starfield struct {
+	stars []star
+	rng   *rand.Rand
+
+	// Color of the closest stars, the far ones fade to black
+	Color color.RGBA
+}
+
+// NewStarfield creates a field of n stars spread over all depths, placed
+// with rng
+func NewStarfield(n int, rng *rand.Rand) *Starfield {
+	s := &Starfield{
+		stars: make([]star, n),
+		rng:   rng,
+		Color: color.RGBA{0xff, 0xff, 0xff, 0xff},
+	}
+	for i := range s.stars {
+		s.respawn(i)
+		s.stars[i].z = starNear + s.rng.Float64()*(1-starNear)
+	}
+	return s
+}
+
+// respawn puts star i back at the far end at a random position
+func (s *Starfield) respawn(i int) {
+	s.stars[i] = star{x: s.rng.Float64()*2 - 1, y: s.rng.Float64()*2 - 1, z: 1}
+}
+
+// Update moves the stars toward the viewer by speed ticks. Stars that pass
+// the viewer or leave the screen start again at the far end.
+func (s *Starfield) Update(speed float64) {
+	for i := range s.stars {
+		st := &s.stars[i]
+		st.z -= starSpeed * speed
+		if st.z < starNear || st.x < -st.z || st.x > st.z || st.y < -st.z || st.y > st.z {
+			s.respawn(i)
+		}
+	}
+}
+
+// Draw projects the stars onto dst, centered, as streaks from where each
+// star was a little further back to where it is now
+func (s *Starfield) Draw(dst *ebiten.Image) {
+	cx := float64(dst.Bounds().Dx()) / 2
+	cy := float64(dst.Bounds().Dy()) / 2
+
+	for _, st := range s.stars {
+		x1, y1 := cx+st.x/st.z*cx, cy+st.y/st.z*cy
+		tail := st.z + starTrail
+		x0, y0 := cx+st.x/tail*cx, cy+st.y/tail*cy
+
+		// Brightness by depth, premultiplied like ebiten colors
+		b := 1 - st.z
+		c := color.RGBA{
+			uint8(float64(s.Color.R) * b),
+			uint8(float64(s.Color.G) * b),
+			uint8(float64(s.Color.B) * b),
+			uint8(float64(s.Color.A) * b),
+		}
+		width := float32(1 + b)
+		vector.StrokeLine(dst, float32(x0), float32(y0), float32(x1), float32(y1), width, c, false)
+		// The streak of a star near the center is too short to show
+		vector.DrawFilledRect(dst, float32(x1), float32(y1), width, width, c, false)
+	}
+}
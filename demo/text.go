@@ -0,0 +1,22 @@
+package demo
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// MeasureText returns the width in pixels of text drawn in the bitmap font
+// at scale, with the letter spacing of SetLetterSpacing between glyphs.
+// Runes missing from the font take no space.
+func (g *Game) MeasureText(text string, scale float64) int {
+	return int(float64(g.font.measureSpaced(text, g.letterSpacing)) * scale)
+}
+
+// drawText draws overlay text at x, y with the letter spacing, matching
+// MeasureText
+func (g *Game) drawText(dst *ebiten.Image, text string, x, y, scale float64) {
+	g.font.drawSpaced(dst, text, x, y, scale, g.letterSpacing)
+}
+
+// drawCentered draws overlay text horizontally centered with its top at y
+func (g *Game) drawCentered(dst *ebiten.Image, text string, y, scale float64) {
+	x := float64(g.width-g.MeasureText(text, scale)) / 2
+	g.drawText(dst, text, x, y, scale)
+}
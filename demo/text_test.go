@@ -0,0 +1,33 @@
+package demo
+
+import "testing"
+
+func TestMeasureText(t *testing.T) {
+	tests := []struct {
+		text    string
+		spacing int
+		scale   float64
+		want    int
+	}{
+		{"", 0, 1, 0},
+		{"COCO", 0, 1, 4 * 48},
+		{"COCO", 0, 0.5, 2 * 48},
+		{"COCO", 0, 3, 12 * 48},
+		// Spacing goes between glyphs, not after the last one
+		{"I", 4, 1, 16},
+		{"COCO", 2, 1, 4*48 + 3*2},
+		{"COCO", 2, 0.5, (4*48 + 3*2) / 2},
+		{"HI!", 0, 1, 48 + 16 + 16},
+		{"A B", 0, 1, 48 + 32 + 48},
+		// Runes the font lacks take no space, nor spacing
+		{"Ab", 2, 1, 48},
+		{"abc", 2, 1, 0},
+		{"A~B", 2, 1, 48 + 2 + 48},
+	}
+	for _, tt := range tests {
+		g := &Game{font: testFont(), letterSpacing: tt.spacing}
+		if got := g.MeasureText(tt.text, tt.scale); got != tt.want {
+			t.Errorf("MeasureText(%q, %v) with spacing %d = %d, want %d", tt.text, tt.scale, tt.spacing, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Music visualizer layout, bottom left of the screen
+const (
+	visualizerBarWidth = 16
+	visualizerBarGap   = 6
+	visualizerHeight   = 120
+	visualizerMargin   = 16
+)
+
+// visualizerColors tints the bass, mid and treble bars
+var visualizerColors = [3]color.RGBA{
+	{0xff, 0x40, 0x40, 0xd0},
+	{0x40, 0xff, 0x40, 0xd0},
+	{0x40, 0x80, 0xff, 0xd0},
+}
+
+// updateVisualizer follows the music's channel levels with an instant rise
+// and a slow fall, so the bars bounce instead of flickering
+func (g *Game) updateVisualizer() {
+	var levels [3]float64
+	if g.music != nil && g.audioPlayer != nil && g.audioPlayer.IsPlaying() {
+		levels = g.music.ChannelLevels()
+	}
+	for i, l := range levels {
+		// Chip music rarely goes past a quarter of full scale
+		l = math.Min(l/0.25, 1)
+		g.visualizerLevels[i] = math.Max(l, g.visualizerLevels[i]-0.02*g.step)
+	}
+}
+
+// drawVisualizer draws one vertical bar per chip voice
+func (g *Game) drawVisualizer(screen *ebiten.Image) {
+	bottom := float32(g.height - visualizerMargin)
+	for i, l := range g.visualizerLevels {
+		x := float32(visualizerMargin + i*(visualizerBarWidth+visualizerBarGap))
+		h := float32(l * visualizerHeight)
+
+		// Dim track, then the level on top of it
+		vector.DrawFilledRect(screen, x, bottom-visualizerHeight, visualizerBarWidth, visualizerHeight, color.RGBA{0, 0, 0, 0x60}, false)
+		vector.DrawFilledRect(screen, x, bottom-h, visualizerBarWidth, h, visualizerColors[i], false)
+	}
+}
@@ -0,0 +1,65 @@
+package demo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WriteWAV writes seconds of 16-bit stereo PCM pulled from pcm, such as a
+// ChipPlayer, to w as a WAV file. It reads through the same Read path the
+// live audio uses, so a short stream is reported as an error. Nothing is
+// written for a negative length.
+func WriteWAV(w io.Writer, pcm io.Reader, sampleRate, seconds int) error {
+	const (
+		channels      = 2
+		bitsPerSample = 16
+		blockAlign    = channels * bitsPerSample / 8
+	)
+	if seconds < 0 {
+		return fmt.Errorf("negative WAV length: %d seconds", seconds)
+	}
+	dataSize := int64(seconds) * int64(sampleRate) * blockAlign
+	if dataSize > 0xffffffff-36 {
+		return fmt.Errorf("%d seconds is too long for a WAV file", seconds)
+	}
+
+	header := struct {
+		RIFF          [4]byte
+		ChunkSize     uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     uint32(36 + dataSize),
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1, // PCM
+		Channels:      channels,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate * blockAlign),
+		BlockAlign:    blockAlign,
+		BitsPerSample: bitsPerSample,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      uint32(dataSize),
+	}
+	if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	n, err := io.CopyN(w, pcm, dataSize)
+	if err == io.EOF {
+		return fmt.Errorf("music ended after %d of %d bytes", n, dataSize)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package demo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteWAV(t *testing.T) {
+	const seconds = 3
+	var buf bytes.Buffer
+	y := newTestPlayer(t, newFakeTune(50000), true)
+	if err := WriteWAV(&buf, y, fakeRate, seconds); err != nil {
+		t.Fatal(err)
+	}
+	wav := buf.Bytes()
+
+	const dataSize = seconds * fakeRate * 4
+	if len(wav) != 44+dataSize {
+		t.Fatalf("WAV is %d bytes, want a 44 byte header and %d of data", len(wav), dataSize)
+	}
+	le := binary.LittleEndian
+	for _, f := range []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"RIFF", string(wav[0:4]), "RIFF"},
+		{"chunk size", le.Uint32(wav[4:]), uint32(36 + dataSize)},
+		{"WAVE", string(wav[8:12]), "WAVE"},
+		{"fmt", string(wav[12:16]), "fmt "},
+		{"fmt size", le.Uint32(wav[16:]), uint32(16)},
+		{"format", le.Uint16(wav[20:]), uint16(1)},
+		{"channels", le.Uint16(wav[22:]), uint16(2)},
+		{"sample rate", le.Uint32(wav[24:]), uint32(fakeRate)},
+		{"byte rate", le.Uint32(wav[28:]), uint32(fakeRate * 4)},
+		{"block align", le.Uint16(wav[32:]), uint16(4)},
+		{"bits per sample", le.Uint16(wav[34:]), uint16(16)},
+		{"data", string(wav[36:40]), "data"},
+		{"data size", le.Uint32(wav[40:]), uint32(dataSize)},
+	} {
+		if f.got != f.want {
+			t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+		}
+	}
+
+	// The data is what Read plays
+	want := make([]byte, dataSize)
+	io.ReadFull(newTestPlayer(t, newFakeTune(50000), true), want)
+	if !bytes.Equal(wav[44:], want) {
+		t.Error("WAV data differs from the player's output")
+	}
+}
+
+func TestWriteWAVErrors(t *testing.T) {
+	// A tune that does not loop runs out before 3 seconds
+	y := newTestPlayer(t, newFakeTune(1000), false)
+	if err := WriteWAV(io.Discard, y, fakeRate, 3); err == nil || !strings.Contains(err.Error(), "ended") {
+		t.Errorf("short tune: err = %v, want the music ending early", err)
+	}
+
+	// A negative length is refused before any of the header is written
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, newTestPlayer(t, newFakeTune(1000), true), fakeRate, -1); err == nil || buf.Len() > 0 {
+		t.Errorf("-1 seconds: err = %v with %d bytes written, want an error and nothing", err, buf.Len())
+	}
+
+	// The sizes must fit the 32-bit header fields
+	if err := WriteWAV(io.Discard, strings.NewReader(""), 44100, 7*3600); err == nil {
+		t.Error("7 hours at 44.1 kHz fit in a WAV file")
+	}
+}
@@ -0,0 +1,441 @@
+package demo
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/olivierh59500/ym-player/pkg/stsound"
+)
+
+// Volume a new player starts at
+const defaultVolume = 0.7
+
+// ymDecoder is the part of stsound's player that YMPlayer drives, so tests
+// can stand a fake in for it
+type ymDecoder interface {
+	LoadMemory(data []byte) error
+	SetLoopMode(loop bool)
+	GetInfo() *stsound.YmMusicInfo
+	Compute(buffer []int16, nbSamples int) bool
+	GetRegister(reg int) int
+	Destroy()
+}
+
+// newStSound creates the stsound decoder the demo plays with
+func newStSound(sampleRate int) ymDecoder {
+	return stsound.CreateWithRate(sampleRate)
+}
+
+// YMPlayer is the ChipPlayer backend for Atari ST YM files
+type YMPlayer struct {
+	player       ymDecoder
+	newDecoder   func(sampleRate int) ymDecoder
+	data         []byte // the tune, kept to restart the decoder on Seek
+	sampleRate   int
+	buffer       []int16
+	mutex        sync.Mutex
+	position     int64
+	totalSamples int64
+	loop         bool
+	ended        bool // Read hit the end of a tune that does not loop
+	stalled      bool // a looping tune failed to decode even restarted
+	stats        PlayerStats
+	volume       float64
+	info         ChipInfo
+	frame        [4]byte // last frame, when split across Read calls
+	pending      []byte  // unread tail of frame
+	peak         float64 // peak of the last Read, 0..1, before volume
+	rms          float64 // RMS of the last Read, 0..1, before volume
+
+	// Band split estimating the three YM voices, see ChannelLevels
+	lowState, midState float64
+	bands              [3]float64
+
+	// The last ymHistorySeconds of decoded samples, before volume, with the
+	// next slot to write; back is how far behind the decoder playback is
+	// while reversing or catching up, see SetReverse
+	history    []int16
+	historyPos int
+	historyLen int
+	back       int
+	reverse    bool
+}
+
+// Crossover frequencies of the ChannelLevels band split, in Hz
+const (
+	ymBassCutoff = 250.0
+	ymMidCutoff  = 2000.0
+)
+
+// NewYMPlayer creates a new YM player instance
+func NewYMPlayer(data []byte, sampleRate int, loop bool) (*YMPlayer, error) {
+	return newYMPlayer(data, sampleRate, loop, newStSound)
+}
+
+// newYMPlayer creates a YM player decoding with the decoders newDecoder
+// makes, one per (re)start of the tune
+func newYMPlayer(data []byte, sampleRate int, loop bool, newDecoder func(sampleRate int) ymDecoder) (*YMPlayer, error) {
+	player := newDecoder(sampleRate)
+
+	if err := player.LoadMemory(data); err != nil {
+		player.Destroy()
+		return nil, fmt.Errorf("failed to load YM data: %w", err)
+	}
+
+	player.SetLoopMode(loop)
+
+	info := player.GetInfo()
+	// A file may not give its length; 0 stands for unknown from here on
+	totalSamples := max(int64(info.MusicTimeInMs)*int64(sampleRate)/1000, 0)
+
+	return &YMPlayer{
+		player:       player,
+		newDecoder:   newDecoder,
+		data:         data,
+		sampleRate:   sampleRate,
+		buffer:       make([]int16, 4096),
+		history:      make([]int16, ymHistorySeconds*sampleRate),
+		totalSamples: totalSamples,
+		loop:         loop,
+		volume:       defaultVolume,
+		info: ChipInfo{
+			Format:   "YM",
+			Duration: time.Duration(totalSamples) * time.Second / time.Duration(sampleRate),
+		},
+	}, nil
+}
+
+var _ ChipPlayer = (*YMPlayer)(nil)
+
+// Read decodes 16-bit little-endian stereo PCM into p. Once a non-looping
+// tune ends it returns the valid prefix it managed to fill together with
+// io.EOF, as io.Reader requires; bytes of p past n are left untouched.
+func (y *YMPlayer) Read(p []byte) (int, error) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	// Closed: Ebiten may still call Read from the audio goroutine
+	if y.player == nil {
+		return 0, io.EOF
+	}
+
+	// Flush the bytes of a frame the previous Read couldn't fit
+	n := copy(p, y.pending)
+	y.pending = y.pending[n:]
+	p = p[n:]
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	// Round up to whole stereo frames; the excess is kept for the next Read
+	samplesNeeded := (len(p) + 3) / 4
+
+	var sumSquares, peak float64
+	var bandSquares [3]float64
+	lowCoef := 1 - math.Exp(-2*math.Pi*ymBassCutoff/float64(y.sampleRate))
+	midCoef := 1 - math.Exp(-2*math.Pi*ymMidCutoff/float64(y.sampleRate))
+	processed := 0
+	for processed < samplesNeeded {
+		chunkSize := samplesNeeded - processed
+		if chunkSize > len(y.buffer) {
+			chunkSize = len(y.buffer)
+		}
+		chunk := y.buffer[:chunkSize]
+
+		if y.reverse || y.back > 0 {
+			// Playing from the history instead of the decoder, see SetReverse
+			chunk = y.replay(chunk)
+		} else {
+			if !y.decode(chunk) {
+				// Only the frames decoded so far are valid
+				y.updateLevels(peak, sumSquares, bandSquares, processed)
+				y.ended = true
+				y.stats.EOFs++
+				return n + processed*4, io.EOF
+			}
+			y.remember(chunk)
+			y.position += int64(chunkSize)
+		}
+
+		for i := range chunk {
+			v := float64(chunk[i])
+			sumSquares += v * v
+			peak = math.Max(peak, math.Abs(v))
+
+			// Two one-pole low-passes split the mix into bass/mid/treble
+			y.lowState += lowCoef * (v - y.lowState)
+			y.midState += midCoef * (v - y.midState)
+			low, mid, high := y.lowState, y.midState-y.lowState, v-y.midState
+			bandSquares[0] += low * low
+			bandSquares[1] += mid * mid
+			bandSquares[2] += high * high
+			y.putFrame(p, (processed+i)*4, int16(v*y.volume))
+		}
+
+		processed += len(chunk)
+	}
+
+	y.updateLevels(peak, sumSquares, bandSquares, processed)
+	return n + len(p), nil
+}
+
+// decode fills chunk from the decoder, reporting false at the end of a tune
+// that does not loop. A looping tune that fails to decode is restarted and
+// tried again; if that fails too the chunk is silence, and no more restarts
+// are tried until the decoder works again. Either way the chunk is filled,
+// so Read always moves on rather than spinning or repeating stale samples.
+func (y *YMPlayer) decode(chunk []int16) bool {
+	if y.player != nil && y.player.Compute(chunk, len(chunk)) {
+		y.stalled = false
+		return true
+	}
+	if !y.loop {
+		return false
+	}
+	if !y.stalled {
+		y.stalled = true
+		if err := y.restart(); err != nil {
+			log.Printf("Failed to restart YM decoder: %v", err)
+		} else if y.player.Compute(chunk, len(chunk)) {
+			y.stalled = false
+			return true
+		}
+	}
+	clear(chunk)
+	y.stats.Underruns++
+	y.stats.SilentFrames += int64(len(chunk))
+	return true
+}
+
+// Stats returns how often Read has had to play silence or stop short
+func (y *YMPlayer) Stats() PlayerStats {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.stats
+}
+
+// updateLevels stores the peak and RMS levels of the frames decoded by a Read
+func (y *YMPlayer) updateLevels(peak, sumSquares float64, bandSquares [3]float64, frames int) {
+	if frames > 0 {
+		y.peak = peak / 32768
+		y.rms = math.Sqrt(sumSquares/float64(frames)) / 32768
+		for i, sq := range bandSquares {
+			y.bands[i] = math.Sqrt(sq/float64(frames)) / 32768
+		}
+	}
+}
+
+// putFrame writes a mono sample to both channels of the little-endian stereo
+// frame at p[off:]. A frame that runs past the end of p is split and its tail
+// left in pending.
+func (y *YMPlayer) putFrame(p []byte, off int, sample int16) {
+	lo, hi := byte(sample), byte(sample>>8)
+	if off+4 <= len(p) {
+		p[off], p[off+1], p[off+2], p[off+3] = lo, hi, lo, hi
+		return
+	}
+	y.frame = [4]byte{lo, hi, lo, hi}
+	k := copy(p[off:], y.frame[:])
+	y.pending = y.frame[k:]
+}
+
+// Seek moves the play position. Like Read it counts bytes of 16-bit stereo
+// PCM, which is what Ebiten's Player.SetPosition seeks with; offsets are
+// rounded down to a whole stereo frame. io.SeekEnd is relative to the end of
+// one pass through the tune, even when looping, and positions are clamped to
+// that pass. For a tune of unknown length io.SeekEnd is an error and
+// positions are only clamped at the start; seeking past the end of a tune
+// that does not loop stops at its end.
+func (y *YMPlayer) Seek(offset int64, whence int) (int64, error) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	var newByte int64
+	switch whence {
+	case io.SeekStart:
+		newByte = offset
+	case io.SeekCurrent:
+		// Bytes already handed out, not counting the undelivered tail
+		newByte = y.position*4 - int64(len(y.pending)) + offset
+	case io.SeekEnd:
+		if y.totalSamples <= 0 {
+			return y.position * 4, fmt.Errorf("seek from end: length unknown")
+		}
+		newByte = y.totalSamples*4 + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	newPos := newByte / 4
+	if newPos < 0 {
+		newPos = 0
+	}
+	if y.totalSamples > 0 && newPos > y.totalSamples {
+		newPos = y.totalSamples
+	}
+
+	if y.player == nil {
+		return y.position * 4, fmt.Errorf("player is closed")
+	}
+	y.ended = false
+
+	// The decoder only plays forward: going back means starting over
+	if newPos < y.position {
+		if err := y.restart(); err != nil {
+			return y.position * 4, err
+		}
+	}
+
+	// Decode and drop the frames up to the new position
+	for y.position < newPos {
+		chunkSize := len(y.buffer)
+		if left := newPos - y.position; left < int64(chunkSize) {
+			chunkSize = int(left)
+		}
+		if !y.player.Compute(y.buffer[:chunkSize], chunkSize) && !y.loop {
+			break
+		}
+		y.position += int64(chunkSize)
+	}
+	y.pending = nil
+	y.historyLen, y.back = 0, 0
+
+	return y.position * 4, nil
+}
+
+// restart reloads the tune so decoding starts again from the beginning. If
+// the tune can't be reloaded the current decoder is kept, so the player is
+// not left closed.
+func (y *YMPlayer) restart() error {
+	player := y.newDecoder(y.sampleRate)
+	if err := player.LoadMemory(y.data); err != nil {
+		player.Destroy()
+		return fmt.Errorf("failed to reload YM data: %w", err)
+	}
+	player.SetLoopMode(y.loop)
+	y.player.Destroy()
+	y.player = player
+	y.position = 0
+	return nil
+}
+
+// Close frees the decoder. Reads after Close return io.EOF; stop the
+// audio.Player reading from it first so no sound is cut mid-buffer.
+func (y *YMPlayer) Close() error {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	if y.player != nil {
+		y.player.Destroy()
+		y.player = nil
+	}
+	y.pending = nil
+	return nil
+}
+
+// PositionMs returns the play position in milliseconds, as far as the
+// decoder has got. Ebiten buffers some audio ahead, so it runs slightly in
+// front of what is heard.
+func (y *YMPlayer) PositionMs() int {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return int((y.position - int64(y.back)) * 1000 / int64(y.sampleRate))
+}
+
+// Duration returns the length of one pass through the tune, 0 when the file
+// does not give it. A looping player plays forever; check IsLooping.
+func (y *YMPlayer) Duration() time.Duration {
+	return time.Duration(y.totalSamples) * time.Second / time.Duration(y.sampleRate)
+}
+
+// IsLooping reports whether the tune restarts when it reaches its end
+func (y *YMPlayer) IsLooping() bool {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.loop
+}
+
+// SetLooping sets whether the tune restarts when it reaches its end
+func (y *YMPlayer) SetLooping(loop bool) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.loop = loop
+	if y.player != nil {
+		y.player.SetLoopMode(loop)
+	}
+}
+
+// Ended reports whether a tune that does not loop has been read to its end.
+// Seeking clears it.
+func (y *YMPlayer) Ended() bool {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.ended
+}
+
+// GetInfo describes the loaded tune
+func (y *YMPlayer) GetInfo() ChipInfo {
+	return y.info
+}
+
+// GetLevels returns the peak and RMS levels of the most recently decoded
+// audio, from 0 (silence) to 1 (full scale). Both ignore the volume setting
+// so visuals keep reacting when the music is turned down.
+func (y *YMPlayer) GetLevels() (peak, rms float64) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.peak, y.rms
+}
+
+// ChannelLevels returns an RMS estimate of the three YM2149 voices, 0..1,
+// for the most recently decoded audio. stsound only hands out the mixed
+// signal, so this splits it into bass, mid and treble bands, which is how
+// chip tunes usually spread their voices; true per-voice levels need the
+// chip registers from upstream.
+func (y *YMPlayer) ChannelLevels() [3]float64 {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.bands
+}
+
+// RegisterSnapshot returns the 16 YM2149 registers of the emulated chip,
+// i.e. the chip state behind the latest Compute. stsound reports R0-R13, the
+// sound registers; R14 and R15 are the chip's I/O ports, which a tune does
+// not play through, and are left at 0. A closed player gives all zeros.
+func (y *YMPlayer) RegisterSnapshot() [16]byte {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	var regs [16]byte
+	if y.player == nil {
+		return regs
+	}
+	for r := range 14 {
+		if v := y.player.GetRegister(r); v >= 0 {
+			regs[r] = byte(v)
+		}
+	}
+	return regs
+}
+
+// CurrentLevel returns the RMS level of the most recently decoded audio.
+func (y *YMPlayer) CurrentLevel() float64 {
+	_, rms := y.GetLevels()
+	return rms
+}
+
+func (y *YMPlayer) GetVolume() float64 {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.volume
+}
+
+func (y *YMPlayer) SetVolume(vol float64) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.volume = vol
+}
@@ -0,0 +1,526 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivierh59500/ym-player/pkg/stsound"
+)
+
+// Sample rate of the test players: 1000 Hz makes milliseconds and samples
+// the same count
+const fakeRate = 1000
+
+// fakeTune is the tune behind the fakeDecoders of a test, with knobs to
+// make them fail
+type fakeTune struct {
+	length   int    // samples in one pass
+	lengthMs uint32 // length GetInfo reports, 0 for unknown
+
+	// sample returns sample k of the pass, rampSample if nil
+	sample func(k int) int16
+
+	failNext int   // Compute calls to fail, counted across decoders
+	broken   bool  // every Compute fails while set
+	loadErr  error // LoadMemory fails with it
+
+	decoders int // decoders created so far
+}
+
+// rampSample is never 0, so decoded audio stands out from silence
+func rampSample(k int) int16 {
+	return int16(k%30000 + 1)
+}
+
+// newFakeTune returns a tune of length samples that reports its length
+func newFakeTune(length int) *fakeTune {
+	return &fakeTune{length: length, lengthMs: uint32(length * 1000 / fakeRate)}
+}
+
+func (ft *fakeTune) newDecoder(int) ymDecoder {
+	ft.decoders++
+	return &fakeDecoder{tune: ft}
+}
+
+func (ft *fakeTune) at(k int) int16 {
+	if ft.sample != nil {
+		return ft.sample(k)
+	}
+	return rampSample(k)
+}
+
+// fakeDecoder plays a fakeTune from its start. Like stsound, the Compute
+// call that runs past the end of a tune that does not loop pads it with
+// silence and succeeds; the calls after that fail.
+type fakeDecoder struct {
+	tune *fakeTune
+	pos  int
+	loop bool
+	over bool
+}
+
+func (d *fakeDecoder) LoadMemory([]byte) error { return d.tune.loadErr }
+func (d *fakeDecoder) SetLoopMode(loop bool)   { d.loop = loop }
+func (d *fakeDecoder) Destroy()                {}
+
+// GetRegister reports R0-R13 like stsound, each a function of the decode
+// position so a snapshot shows which frame it came from
+func (d *fakeDecoder) GetRegister(reg int) int {
+	if reg < 0 || reg > 13 {
+		return -1
+	}
+	return (d.pos/fakeFrame + reg) & 0xff
+}
+
+// Samples per frame of the fake registers, like a 50 Hz YM tune
+const fakeFrame = fakeRate / 50
+
+func (d *fakeDecoder) GetInfo() *stsound.YmMusicInfo {
+	return &stsound.YmMusicInfo{MusicTimeInMs: stsound.YmU32(d.tune.lengthMs)}
+}
+
+func (d *fakeDecoder) Compute(buf []int16, n int) bool {
+	if d.tune.broken || d.tune.failNext > 0 || d.over {
+		if d.tune.failNext > 0 {
+			d.tune.failNext--
+		}
+		clear(buf[:n])
+		return false
+	}
+	for i := range buf[:n] {
+		if d.pos >= d.tune.length {
+			if !d.loop {
+				clear(buf[i:n])
+				d.over = true
+				return true
+			}
+			d.pos = 0
+		}
+		buf[i] = d.tune.at(d.pos)
+		d.pos++
+	}
+	return true
+}
+
+// newTestPlayer returns a player of tune at full volume
+func newTestPlayer(t testing.TB, tune *fakeTune, loop bool) *YMPlayer {
+	t.Helper()
+	y, err := newYMPlayer(nil, fakeRate, loop, tune.newDecoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y.SetVolume(1)
+	return y
+}
+
+// readSamples reads n frames from y and returns their left channel
+func readSamples(t *testing.T, y *YMPlayer, n int) []int16 {
+	t.Helper()
+	p := make([]byte, n*4)
+	got, err := y.Read(p)
+	if err != nil || got != len(p) {
+		t.Fatalf("Read(%d bytes) = %d, %v", len(p), got, err)
+	}
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(uint16(p[i*4]) | uint16(p[i*4+1])<<8)
+	}
+	return samples
+}
+
+func TestYMPlayerRestartsFailedDecoder(t *testing.T) {
+	tune := newFakeTune(50000)
+	y := newTestPlayer(t, tune, true)
+	readSamples(t, y, 100)
+
+	// One failure: the tune restarts and plays on from its start
+	tune.failNext = 1
+	got := readSamples(t, y, 10)
+	if got[0] != rampSample(0) {
+		t.Errorf("first sample after the restart = %d, want %d", got[0], rampSample(0))
+	}
+	if tune.decoders != 2 {
+		t.Errorf("decoders created = %d, want 2", tune.decoders)
+	}
+	if s := y.Stats(); s.Underruns != 0 {
+		t.Errorf("underruns = %d, want 0", s.Underruns)
+	}
+}
+
+func TestYMPlayerStalledDecoder(t *testing.T) {
+	tune := newFakeTune(50000)
+	y := newTestPlayer(t, tune, true)
+
+	// A decoder that keeps failing is restarted once, then Read plays
+	// silence without trying again
+	tune.broken = true
+	for range 5 {
+		for i, s := range readSamples(t, y, 100) {
+			if s != 0 {
+				t.Fatalf("sample %d = %d while stalled, want silence", i, s)
+			}
+		}
+	}
+	if tune.decoders != 2 {
+		t.Errorf("decoders created = %d, want 2", tune.decoders)
+	}
+	if s := y.Stats(); s.Underruns != 5 || s.SilentFrames != 500 {
+		t.Errorf("stats = %+v, want 5 underruns of 500 frames", s)
+	}
+
+	// Once the decoder works again the music comes back, without a restart
+	tune.broken = false
+	if got := readSamples(t, y, 10); got[0] != rampSample(0) {
+		t.Errorf("first sample after the stall = %d, want %d", got[0], rampSample(0))
+	}
+	if tune.decoders != 2 {
+		t.Errorf("decoders created = %d, want 2", tune.decoders)
+	}
+}
+
+func TestYMPlayerFailedRestart(t *testing.T) {
+	tune := newFakeTune(50000)
+	y := newTestPlayer(t, tune, true)
+
+	// The restart can't load the tune: a chunk of silence, not a crash, a
+	// spin or the end of the stream
+	tune.failNext = 1
+	tune.loadErr = errors.New("corrupt")
+	for i, s := range readSamples(t, y, 100) {
+		if s != 0 {
+			t.Fatalf("sample %d = %d after a failed restart, want silence", i, s)
+		}
+	}
+
+	// The decoder it kept plays on once it works again
+	if got := readSamples(t, y, 10); got[0] != rampSample(0) {
+		t.Errorf("first sample after the failure = %d, want %d", got[0], rampSample(0))
+	}
+}
+
+func TestYMPlayerLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		sample    func(k int) int16
+		peak, rms float64
+	}{
+		{"silence", func(int) int16 { return 0 }, 0, 0},
+		{"square", func(k int) int16 {
+			if k%2 == 0 {
+				return 16384
+			}
+			return -16384
+		}, 0.5, 0.5},
+		// 100 samples per period, so a 1000 frame read holds whole periods
+		{"sine", func(k int) int16 {
+			return int16(math.Round(24576 * math.Sin(2*math.Pi*float64(k)/100)))
+		}, 0.75, 0.75 / math.Sqrt2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tune := newFakeTune(50000)
+			tune.sample = tt.sample
+			y := newTestPlayer(t, tune, true)
+
+			// Volume must not change the levels the visuals react to
+			y.SetVolume(0.1)
+			readSamples(t, y, 1000)
+			peak, rms := y.GetLevels()
+			if math.Abs(peak-tt.peak) > 1e-3 || math.Abs(rms-tt.rms) > 1e-3 {
+				t.Errorf("GetLevels() = %.4f, %.4f, want %.4f, %.4f", peak, rms, tt.peak, tt.rms)
+			}
+		})
+	}
+}
+
+func TestYMPlayerPartialFrames(t *testing.T) {
+	const total = 4 * 3000
+	want := make([]byte, total)
+	if n, err := newTestPlayer(t, newFakeTune(50000), true).Read(want); n != total || err != nil {
+		t.Fatalf("Read(%d bytes) = %d, %v", total, n, err)
+	}
+
+	for _, sizes := range [][]int{{1}, {3}, {5}, {1, 3, 5, 4, 2}} {
+		y := newTestPlayer(t, newFakeTune(50000), true)
+		got := make([]byte, 0, total)
+		for i := 0; len(got) < total; i++ {
+			p := make([]byte, min(sizes[i%len(sizes)], total-len(got)))
+			n, err := y.Read(p)
+			if n != len(p) || err != nil {
+				t.Fatalf("sizes %v: Read(%d bytes) = %d, %v", sizes, len(p), n, err)
+			}
+			got = append(got, p...)
+		}
+		if !bytes.Equal(got, want) {
+			i := 0
+			for got[i] == want[i] {
+				i++
+			}
+			t.Errorf("sizes %v: stream differs from a single read at byte %d", sizes, i)
+		}
+	}
+}
+
+func TestYMPlayerReadAllocs(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(50000), true)
+	// An odd size, so the split frame path runs too
+	p := make([]byte, 8190)
+	if allocs := testing.AllocsPerRun(100, func() { y.Read(p) }); allocs != 0 {
+		t.Errorf("Read allocates %v times per call, want 0", allocs)
+	}
+}
+
+// BenchmarkYMPlayerRead times Read alone, the fake decoder costing next to
+// nothing; Ebiten asks for about this much per callback
+func BenchmarkYMPlayerRead(b *testing.B) {
+	y := newTestPlayer(b, newFakeTune(50000), true)
+	p := make([]byte, 8192)
+	b.SetBytes(int64(len(p)))
+	b.ReportAllocs()
+	for b.Loop() {
+		y.Read(p)
+	}
+}
+
+func TestYMPlayerReadEOF(t *testing.T) {
+	// Past the end of a tune stsound pads one Compute call with silence; the
+	// call after it fails. Reading 10000 frames in 4096 frame chunks then
+	// decodes two chunks before the end.
+	y := newTestPlayer(t, newFakeTune(5000), false)
+	p := bytes.Repeat([]byte{0xaa}, 10000*4)
+	n, err := y.Read(p)
+	if n != 2*4096*4 || err != io.EOF {
+		t.Fatalf("Read = %d, %v, want %d, EOF", n, err, 2*4096*4)
+	}
+	for i := n; i < len(p); i++ {
+		if p[i] != 0xaa {
+			t.Fatalf("Read wrote byte %d, past the %d it returned", i, n)
+		}
+	}
+	if last := int16(uint16(p[4999*4]) | uint16(p[4999*4+1])<<8); last != rampSample(4999) {
+		t.Errorf("last sample of the tune = %d, want %d", last, rampSample(4999))
+	}
+	if !y.Ended() {
+		t.Error("Ended() = false after EOF")
+	}
+	if s := y.Stats(); s.EOFs != 1 || s.Underruns != 0 {
+		t.Errorf("stats = %+v, want 1 EOF", s)
+	}
+
+	// Reads after the end return nothing
+	n, err = y.Read(p)
+	if n != 0 || err != io.EOF {
+		t.Errorf("Read after EOF = %d, %v, want 0, EOF", n, err)
+	}
+
+	// Seeking back plays the tune again
+	if _, err := y.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if y.Ended() {
+		t.Error("Ended() = true after Seek")
+	}
+	if got := readSamples(t, y, 10); got[0] != rampSample(0) {
+		t.Errorf("first sample after Seek = %d, want %d", got[0], rampSample(0))
+	}
+}
+
+func TestYMPlayerDuration(t *testing.T) {
+	for _, loop := range []bool{false, true} {
+		y := newTestPlayer(t, newFakeTune(5000), loop)
+		if got := y.Duration(); got != 5*time.Second {
+			t.Errorf("loop %v: Duration() = %v, want 5s", loop, got)
+		}
+		if got := y.IsLooping(); got != loop {
+			t.Errorf("IsLooping() = %v, want %v", got, loop)
+		}
+	}
+
+	y := newTestPlayer(t, newFakeTune(5000), false)
+	y.SetLooping(true)
+	if !y.IsLooping() {
+		t.Error("IsLooping() = false after SetLooping(true)")
+	}
+}
+
+func TestYMPlayerSeek(t *testing.T) {
+	tests := []struct {
+		name   string
+		loop   bool
+		start  int64 // frame to Seek to first, before the tested Seek
+		offset int64 // in frames
+		whence int
+		want   int64 // frame
+	}{
+		{"forward", false, 0, 3000, io.SeekStart, 3000},
+		{"backward", false, 4000, 1000, io.SeekStart, 1000},
+		{"current forward", false, 1000, 500, io.SeekCurrent, 1500},
+		{"current backward", false, 1000, -500, io.SeekCurrent, 500},
+		{"before the start", false, 1000, -2000, io.SeekCurrent, 0},
+		{"past the end", false, 0, 9000, io.SeekStart, 5000},
+		{"past the end looping", true, 0, 9000, io.SeekStart, 5000},
+		// SeekEnd is relative to one pass, looping or not
+		{"end", false, 0, -1000, io.SeekEnd, 4000},
+		{"end looping", true, 0, -1000, io.SeekEnd, 4000},
+		{"end exactly", true, 0, 0, io.SeekEnd, 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tune := newFakeTune(5000)
+			y := newTestPlayer(t, tune, tt.loop)
+			if _, err := y.Seek(tt.start*4, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			got, err := y.Seek(tt.offset*4, tt.whence)
+			if err != nil || got != tt.want*4 {
+				t.Fatalf("Seek(%d, %d) = %d, %v, want %d", tt.offset*4, tt.whence, got, err, tt.want*4)
+			}
+			if pos := y.PositionMs(); pos != int(tt.want) {
+				t.Errorf("PositionMs() = %d, want %d", pos, tt.want)
+			}
+			// The samples read next are the ones at the new position
+			if tt.want < 5000 {
+				if s := readSamples(t, y, 1)[0]; s != rampSample(int(tt.want)) {
+					t.Errorf("sample after Seek = %d, want %d", s, rampSample(int(tt.want)))
+				}
+			}
+		})
+	}
+}
+
+func TestYMPlayerSeekOddOffset(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(5000), false)
+	if got, err := y.Seek(4*1000+3, io.SeekStart); got != 4*1000 || err != nil {
+		t.Errorf("Seek(4003) = %d, %v, want 4000, rounded down to a frame", got, err)
+	}
+	if _, err := y.Seek(0, 42); err == nil {
+		t.Error("Seek with an invalid whence succeeded")
+	}
+}
+
+func TestYMPlayerUnknownLength(t *testing.T) {
+	tune := newFakeTune(5000)
+	tune.lengthMs = 0
+	y := newTestPlayer(t, tune, true)
+
+	if got := y.Duration(); got != 0 {
+		t.Errorf("Duration() = %v, want 0", got)
+	}
+	if got := y.GetInfo().Duration; got != 0 {
+		t.Errorf("GetInfo().Duration = %v, want 0", got)
+	}
+	if _, err := y.Seek(0, io.SeekEnd); err == nil {
+		t.Error("Seek from the end of a tune of unknown length succeeded")
+	}
+
+	// With no end to clamp to, a Seek goes where it's asked, into the next
+	// pass of the looping tune
+	if got, err := y.Seek(7000*4, io.SeekStart); got != 7000*4 || err != nil {
+		t.Fatalf("Seek(7000 frames) = %d, %v, want %d", got, err, 7000*4)
+	}
+	if s := readSamples(t, y, 1)[0]; s != rampSample(2000) {
+		t.Errorf("sample after Seek = %d, want %d", s, rampSample(2000))
+	}
+}
+
+func TestYMPlayerStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		loop   bool
+		length int
+		broken bool // the decoder fails from the first Read
+		reads  int  // Reads of 1000 frames
+		want   PlayerStats
+	}{
+		{"playing", true, 50000, false, 3, PlayerStats{}},
+		{"looping past the end", true, 1500, false, 3, PlayerStats{}},
+		// Restarted once on the first Read, then silence
+		{"underruns", true, 50000, true, 3, PlayerStats{Underruns: 3, SilentFrames: 3000}},
+		// The first Read is padded to the end, every one after it stops short
+		{"end", false, 500, false, 3, PlayerStats{EOFs: 2}},
+		{"broken without looping", false, 50000, true, 2, PlayerStats{EOFs: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tune := newFakeTune(tt.length)
+			tune.broken = tt.broken
+			y := newTestPlayer(t, tune, tt.loop)
+			p := make([]byte, 1000*4)
+			for range tt.reads {
+				y.Read(p)
+			}
+			if got := y.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYMPlayerReadAfterClose(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(50000), true)
+	readSamples(t, y, 10)
+	y.Close()
+
+	p := make([]byte, 4*100)
+	if n, err := y.Read(p); n != 0 || err != io.EOF {
+		t.Errorf("Read after Close = %d, %v, want 0, EOF", n, err)
+	}
+	if _, err := y.Seek(0, io.SeekStart); err == nil {
+		t.Error("Seek after Close succeeded")
+	}
+	if err := y.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
+
+// TestYMPlayerCloseWhileReading closes the player while another goroutine
+// keeps reading from it, as Ebiten's audio goroutine may
+func TestYMPlayerCloseWhileReading(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(50000), true)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p := make([]byte, 4*100)
+		for {
+			if _, err := y.Read(p); err == io.EOF {
+				return
+			}
+		}
+	}()
+	y.Close()
+	wg.Wait()
+}
+
+func TestYMPlayerRegisterSnapshot(t *testing.T) {
+	y := newTestPlayer(t, newFakeTune(50000), true)
+
+	// The registers follow the decoder, frame by frame
+	for _, frames := range []int{0, 1, 3, 10} {
+		if frames > 0 {
+			readSamples(t, y, frames*fakeFrame)
+		}
+		frame := int(y.position) / fakeFrame
+		regs := y.RegisterSnapshot()
+		for r := range 16 {
+			want := byte(frame + r)
+			if r > 13 {
+				want = 0 // the I/O ports stsound does not report
+			}
+			if regs[r] != want {
+				t.Errorf("frame %d: R%d = %d, want %d", frame, r, regs[r], want)
+			}
+		}
+	}
+
+	// A closed player has no chip to read
+	y.Close()
+	if regs := y.RegisterSnapshot(); regs != [16]byte{} {
+		t.Errorf("registers after Close = % x, want zeros", regs[:])
+	}
+}
@@ -0,0 +1,76 @@
+package demo
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// How much decoded audio YMPlayer keeps for reverse playback. Reversing
+// further back than this plays silence.
+const ymHistorySeconds = 4
+
+// reverser is implemented by backends that can play backward, like YMPlayer
+type reverser interface {
+	SetReverse(on bool)
+}
+
+// SetReverse plays the music backward while on. stsound only decodes
+// forward, so reverse playback walks back through the last
+// ymHistorySeconds of decoded samples, then goes silent. Turned off, the
+// music plays forward again from where the reverse stopped, through the
+// same history, and only then resumes decoding: reversing and releasing
+// rocks the tune back and forth without a jump.
+func (y *YMPlayer) SetReverse(on bool) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.reverse = on
+}
+
+// remember appends decoded samples to the history ring
+func (y *YMPlayer) remember(samples []int16) {
+	size := len(y.history)
+	if size == 0 {
+		return
+	}
+	if len(samples) > size {
+		samples = samples[len(samples)-size:]
+	}
+	n := copy(y.history[y.historyPos:], samples)
+	copy(y.history, samples[n:])
+	y.historyPos = (y.historyPos + len(samples)) % size
+	y.historyLen = min(y.historyLen+len(samples), size)
+}
+
+// replay fills buf from the history: backward while reversing, silence once
+// the history runs out; forward otherwise, stopping early when it catches up
+// with the decoder. It returns the part of buf filled.
+func (y *YMPlayer) replay(buf []int16) []int16 {
+	size := len(y.history)
+	for i := range buf {
+		if y.reverse {
+			if y.back >= y.historyLen {
+				buf[i] = 0
+				continue
+			}
+			y.back++
+			buf[i] = y.history[(y.historyPos-y.back+size)%size]
+			continue
+		}
+		if y.back == 0 {
+			return buf[:i]
+		}
+		buf[i] = y.history[(y.historyPos-y.back+size)%size]
+		y.back--
+	}
+	return buf
+}
+
+// updateReverse plays the music backward while the reverse key is held
+func (g *Game) updateReverse() {
+	r, ok := g.music.(reverser)
+	if !ok {
+		return
+	}
+	want := g.state == "demo" && ebiten.IsKeyPressed(g.keys.Reverse)
+	if want != g.reversing {
+		g.reversing = want
+		r.SetReverse(want)
+	}
+}